@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	_ "modernc.org/sqlite"
+	"wherd.dev/chad/internal/migrate"
+)
+
+const dataUsage = "usage: chad data <inspect | migrate> <file>"
+
+// runData handles the offline data file commands, for JSON files and SQLite databases.
+func runData(args []string) error {
+	if len(args) != 2 {
+		return errors.New(dataUsage)
+	}
+
+	database, err := isSQLite(args[1])
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case args[0] == "inspect" && database:
+		return inspectDatabase(args[1])
+	case args[0] == "inspect":
+		return inspectData(args[1])
+	case args[0] == "migrate" && database:
+		return migrateDatabase(args[1])
+	case args[0] == "migrate":
+		return migrateData(args[1])
+	}
+
+	return errors.New(dataUsage)
+}
+
+// isSQLite tells databases from JSON files by their header.
+func isSQLite(path string) (bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	header := make([]byte, 16)
+	if _, err := io.ReadFull(file, header); err != nil {
+		return false, nil
+	}
+
+	return bytes.Equal(header, []byte("SQLite format 3\x00")), nil
+}
+
+func inspectData(path string) error {
+	doc, err := migrate.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("File:        %s\n", path)
+	fmt.Printf("Version:     %s (latest %s)\n", doc.Version(), migrate.Latest())
+	if timestamp, ok := doc["timestamp"].(float64); ok {
+		fmt.Printf("Saved:       %s\n", time.Unix(int64(timestamp), 0).Format("2006-01-02 15:04:05"))
+	}
+
+	for _, section := range []string{"reminders", "guilds", "history", "summaries", "rate_limits", "usage"} {
+		count := 0
+		switch value := doc[section].(type) {
+		case []any:
+			count = len(value)
+		case map[string]any:
+			count = len(value)
+		}
+		fmt.Printf("%-12s %d\n", section+":", count)
+	}
+
+	pending, err := migrate.Pending(doc)
+	if err != nil {
+		return err
+	}
+
+	if len(pending) == 0 {
+		fmt.Println("Up to date")
+		return nil
+	}
+
+	for _, step := range pending {
+		fmt.Printf("Pending migration from %s to %s\n", step.From, step.To)
+	}
+
+	return nil
+}
+
+func migrateData(path string) error {
+	applied, backup, err := migrate.MigrateFile(path)
+	if err != nil {
+		return err
+	}
+
+	if len(applied) == 0 {
+		fmt.Printf("%s is already at version %s\n", path, migrate.Latest())
+		return nil
+	}
+
+	for _, step := range applied {
+		fmt.Printf("Migrated from %s to %s\n", step.From, step.To)
+	}
+	fmt.Printf("Backup written to %s\n", backup)
+
+	return nil
+}
+
+func inspectDatabase(path string) error {
+	db, err := sql.Open("sqlite", path+"?mode=ro")
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	version, err := migrate.SQLVersion(db)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Database:    %s\n", path)
+	fmt.Printf("Version:     %s (latest %s)\n", version, migrate.LatestSQL())
+
+	for _, table := range []string{"reminders", "guilds", "users", "history", "summaries", "rate_limits", "offenses", "usage"} {
+		count := 0
+		// Tables are created as they are needed, older databases miss some
+		if err := db.QueryRow("SELECT count(*) FROM " + table).Scan(&count); err != nil {
+			continue
+		}
+		fmt.Printf("%-12s %d\n", table+":", count)
+	}
+
+	pending, err := migrate.PendingSQL(version)
+	if err != nil {
+		return err
+	}
+
+	if len(pending) == 0 {
+		fmt.Println("Up to date")
+		return nil
+	}
+
+	for _, step := range pending {
+		fmt.Printf("Pending migration from %s to %s\n", step.From, step.To)
+	}
+
+	return nil
+}
+
+func migrateDatabase(path string) error {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	applied, backup, err := migrate.MigrateSQL(db, path)
+	for _, step := range applied {
+		fmt.Printf("Migrated from %s to %s\n", step.From, step.To)
+	}
+	if backup != "" {
+		fmt.Printf("Backup written to %s\n", backup)
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(applied) == 0 {
+		fmt.Printf("%s is already at version %s\n", path, migrate.LatestSQL())
+	}
+
+	return nil
+}
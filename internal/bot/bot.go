@@ -13,43 +13,88 @@ import (
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/charmbracelet/log"
+	"wherd.dev/chad/internal/cache"
 	"wherd.dev/chad/internal/config"
 	"wherd.dev/chad/internal/openrouter"
+	"wherd.dev/chad/internal/ratelimit"
+	"wherd.dev/chad/internal/tools"
+	"wherd.dev/chad/internal/webpage"
+	"wherd.dev/chad/internal/websearch"
 )
 
 type Bot struct {
 	config  *config.Config
 	session *discordgo.Session
+	store   Store
 
 	ctx    context.Context
 	cancel context.CancelFunc
 
 	mutex          sync.RWMutex
-	rateLimits     map[string][]int64
+	saveMutex      sync.Mutex
+	dirty          dirtyState
+	limiter        *ratelimit.Limiter
+	offenses       map[string]*Offense
+	usage          map[string]*Usage
 	memberCache    map[string]string
-	messageHistory map[string][]*openrouter.Message
+	messageHistory map[string][]*HistoryEntry
+	summaries      map[string]*Summary
+	summarizing    map[string]bool      // Channels being summarized right now
+	summaryFailed  map[string]time.Time // When summarizing the channel last failed
+	contextKept    map[string]int       // Latest messages of the channel that fit in the last request
+	guildSettings  map[string]*GuildSettings
+	userSettings   map[string]*UserSettings
+	commands       *CommandRegistry
+	tools          *tools.Registry
+	search         websearch.Provider // nil when no provider is configured
+	pages          *webpage.Fetcher
+
+	searchCache     *cache.Cache[[]*websearch.WebResult]
+	completionCache *cache.Cache[*openrouter.Response]
 
 	reminders       []*Reminder
-	reminderTimers  map[string]*time.Timer
+	reminderQueue   reminderQueue
+	reminderWake    chan struct{}
 	reminderCounter int64
 }
 
 func New(config *config.Config) *Bot {
 	ctx, cancel := context.WithCancel(context.Background())
-	return &Bot{
+	b := &Bot{
 		config: config,
 
 		ctx:    ctx,
 		cancel: cancel,
 
 		mutex:       sync.RWMutex{},
-		rateLimits:  map[string][]int64{},
+		dirty:       newDirtyState(),
+		limiter:     newLimiter(config.RateLimit),
+		offenses:    map[string]*Offense{},
+		usage:       map[string]*Usage{},
 		memberCache: map[string]string{},
 
-		messageHistory: map[string][]*openrouter.Message{},
+		messageHistory: map[string][]*HistoryEntry{},
+		summaries:      map[string]*Summary{},
+		summarizing:    map[string]bool{},
+		summaryFailed:  map[string]time.Time{},
+		contextKept:    map[string]int{},
+		guildSettings:  map[string]*GuildSettings{},
+		userSettings:   map[string]*UserSettings{},
 		reminders:      []*Reminder{},
-		reminderTimers: map[string]*time.Timer{},
+		reminderWake:   make(chan struct{}, 1),
+		commands:       NewCommandRegistry(),
+		tools:          tools.NewRegistry(),
+		pages:          newPageFetcher(config.Search.Fetch),
+
+		searchCache:     newCache[[]*websearch.WebResult](config.Cache.Search),
+		completionCache: newCache[*openrouter.Response](config.Cache.Completions),
 	}
+
+	b.search = newSearchProvider(config, b.searchCache)
+
+	b.registerCommands()
+	b.registerTools()
+	return b
 }
 
 func (b *Bot) Run() error {
@@ -62,6 +107,10 @@ func (b *Bot) Run() error {
 	}
 
 	var err error
+	if b.store, err = openStore(b.config.Storage); err != nil {
+		return fmt.Errorf("could not open storage: %w", err)
+	}
+
 	if b.session, err = discordgo.New("Bot " + b.config.DiscordToken); err != nil {
 		return err
 	}
@@ -73,6 +122,7 @@ func (b *Bot) Run() error {
 	b.session.AddHandler(b.memberUpdate)
 	b.session.AddHandler(b.memberLeave)
 	b.session.AddHandler(b.messageCreate)
+	b.session.AddHandler(b.interactionCreate)
 
 	b.session.Identify.Intents = discordgo.IntentsGuildMessages |
 		discordgo.IntentsMessageContent |
@@ -88,6 +138,10 @@ func (b *Bot) Run() error {
 		log.Warnf("Could not load existing data: %v", err)
 	}
 
+	if err = b.loadCaches(); err != nil {
+		log.Warnf("Could not load caches: %v", err)
+	}
+
 	b.initializeReminders()
 	// go b.cleanupTasks()
 	go b.autoSaveData()
@@ -98,8 +152,7 @@ func (b *Bot) Run() error {
 	<-sc
 
 	// Gracefully shutdown
-	b.shutdown()
-	return b.session.Close()
+	return b.shutdown()
 }
 
 func (b *Bot) ready(s *discordgo.Session, event *discordgo.Ready) {
@@ -107,7 +160,9 @@ func (b *Bot) ready(s *discordgo.Session, event *discordgo.Ready) {
 	log.Infof("Bot is in %d servers", len(s.State.Guilds))
 
 	// Set bot status
-	s.UpdateGameStatus(0, "!help for commands")
+	s.UpdateGameStatus(0, b.config.Prefix+"help for commands")
+
+	go b.registerSlashCommands(s, event.Guilds)
 }
 
 func (b *Bot) guildCreate(s *discordgo.Session, event *discordgo.GuildCreate) {
@@ -145,6 +200,8 @@ func (b *Bot) memberJoin(s *discordgo.Session, event *discordgo.GuildMemberAdd)
 		b.memberCache[event.Member.Nick] = event.Member.User.ID
 	}
 	b.mutex.Unlock()
+
+	b.welcomeMember(s, event.Member)
 }
 
 func (b *Bot) memberUpdate(s *discordgo.Session, event *discordgo.GuildMemberUpdate) {
@@ -182,28 +239,24 @@ func (b *Bot) messageCreate(s *discordgo.Session, event *discordgo.MessageCreate
 		return
 	}
 
-	// Rate limiting check
-	if b.isRateLimited(event.Author.ID) {
-		if err := s.MessageReactionAdd(event.ChannelID, event.ID, "⏰"); err != nil {
-			log.Printf("Failed to add warning reaction: %v", err)
-		}
-
-		timeoutUntil := time.Now().Add(time.Duration(b.config.RateLimit.MuteTime))
-		err := s.GuildMemberTimeout(event.GuildID, event.Author.ID, &timeoutUntil)
-		if err != nil {
-			log.Errorf("Failed to timeout user %s: %v", event.Author.Username, err)
+	// Rate limiting check, plain chat never counts
+	if action := b.messageAction(s, event); action != "message" {
+		if p, notice := b.enforceRateLimit(s, event.GuildID, event.ChannelID, event.Member, event.Author, action); p != penaltyNone {
+			b.notifyPenalty(s, event, p, notice)
+			return
 		}
-
-		return
 	}
 
-	b.storeMessageForContext(event.ChannelID, &openrouter.Message{
-		Role:    "user",
-		Content: fmt.Sprintf("%s: %s", event.Author.Username, event.Content),
+	b.storeMessageForContext(event.GuildID, event.ChannelID, &HistoryEntry{
+		MessageID: event.ID,
+		AuthorID:  event.Author.ID,
+		Role:      "user",
+		Content:   fmt.Sprintf("%s: %s", event.Author.Username, event.Content),
+		Timestamp: event.Timestamp.Unix(),
 	})
 
 	// Check if message starts with bot prefix
-	if strings.HasPrefix(event.Content, b.config.Prefix) {
+	if strings.HasPrefix(event.Content, b.guildPrefix(event.GuildID)) {
 		b.handleCommand(s, event)
 		return
 	}
@@ -224,47 +277,6 @@ func (b *Bot) messageCreate(s *discordgo.Session, event *discordgo.MessageCreate
 	}
 }
 
-func (b *Bot) isRateLimited(userID string) bool {
-	now := time.Now().Unix()
-
-	b.mutex.Lock()
-	defer b.mutex.Unlock()
-
-	if timestamps, ok := b.rateLimits[userID]; ok {
-		for i, t := range timestamps {
-			if t < now {
-				timestamps[i] = now + b.config.RateLimit.Window
-				return false
-			}
-		}
-	} else {
-		b.rateLimits[userID] = make([]int64, b.config.RateLimit.MaxRequests)
-		b.rateLimits[userID][0] = now + b.config.RateLimit.Window
-		return false
-	}
-
-	return true
-}
-
-func (b *Bot) storeMessageForContext(channelID string, message *openrouter.Message) {
-	b.mutex.Lock()
-
-	if _, ok := b.messageHistory[channelID]; !ok {
-		b.messageHistory[channelID] = make([]*openrouter.Message, 0, b.config.OpenRouter.MaxMessagesInContext)
-	}
-
-	history := b.messageHistory[channelID]
-	history = append(history, message)
-
-	// Keep only last {MaxMessagesInContext} messages
-	if len(history) > b.config.OpenRouter.MaxMessagesInContext {
-		history = history[len(history)-b.config.OpenRouter.MaxMessagesInContext:]
-	}
-
-	b.messageHistory[channelID] = history
-	b.mutex.Unlock()
-}
-
 func (b *Bot) autoSaveData() {
 	ticker := time.NewTicker(time.Duration(b.config.AutoSaveInterval) * time.Second)
 	defer ticker.Stop()
@@ -275,6 +287,9 @@ func (b *Bot) autoSaveData() {
 			if err := b.saveSettings(); err != nil {
 				log.Errorf("Auto-save failed: %v", err)
 			}
+			if err := b.saveCaches(); err != nil {
+				log.Errorf("Failed to save caches: %v", err)
+			}
 		case <-b.ctx.Done():
 			log.Info("Shutting down auto-save routine")
 			return
@@ -282,7 +297,7 @@ func (b *Bot) autoSaveData() {
 	}
 }
 
-func (b *Bot) shutdown() {
+func (b *Bot) shutdown() error {
 	log.Print("Initiating shutdown...")
 
 	// Cancel background tasks
@@ -296,13 +311,31 @@ func (b *Bot) shutdown() {
 		time.Sleep(1 * time.Second) // Give time for status to update
 	}
 
-	// Stop all reminder timers
-	b.shutdownReminders()
+	// Stop handling events, so nothing changes the state while it is saved
+	var err error
+	if b.session != nil {
+		err = b.session.Close()
+	}
 
 	// Save data before exiting
 	if err := b.saveSettings(); err != nil {
 		log.Errorf("Failed to save data during shutdown: %v", err)
 	}
 
+	if err := b.saveCaches(); err != nil {
+		log.Errorf("Failed to save caches during shutdown: %v", err)
+	}
+	b.logCacheStats(log.Infof)
+
+	// Goroutines like the reminder scheduler or a summary can still be finishing
+	b.saveMutex.Lock()
+	b.mutex.RLock()
+	if err := b.store.Close(); err != nil {
+		log.Errorf("Failed to close storage: %v", err)
+	}
+	b.mutex.RUnlock()
+	b.saveMutex.Unlock()
+
 	log.Print("Shutdown complete")
+	return err
 }
@@ -0,0 +1,88 @@
+package bot
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"time"
+
+	"wherd.dev/chad/internal/cache"
+	"wherd.dev/chad/internal/config"
+	"wherd.dev/chad/internal/openrouter"
+	"wherd.dev/chad/internal/websearch"
+)
+
+// cacheFile is how the caches are saved between restarts.
+type cacheFile struct {
+	Search      []cache.Entry[[]*websearch.WebResult] `json:"search"`
+	Completions []cache.Entry[*openrouter.Response]   `json:"completions"`
+}
+
+func newCache[V any](cfg config.CacheBucket) *cache.Cache[V] {
+	return cache.New[V](cfg.Size, time.Duration(cfg.TTL)*time.Second)
+}
+
+// loadCaches restores the caches saved by saveCaches, if they are kept on disk.
+func (b *Bot) loadCaches() error {
+	if b.config.Cache.Path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(b.config.Cache.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	file := &cacheFile{}
+	if err := json.Unmarshal(data, file); err != nil {
+		return err
+	}
+
+	b.searchCache.Restore(file.Search)
+	b.completionCache.Restore(file.Completions)
+	return nil
+}
+
+// saveCaches writes the caches to disk, if they are kept there.
+func (b *Bot) saveCaches() error {
+	if b.config.Cache.Path == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(&cacheFile{
+		Search:      b.searchCache.Entries(),
+		Completions: b.completionCache.Entries(),
+	})
+	if err != nil {
+		return err
+	}
+
+	tempFile := b.config.Cache.Path + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0644); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tempFile, b.config.Cache.Path); err != nil {
+		os.Remove(tempFile)
+		return err
+	}
+
+	return nil
+}
+
+// logCacheStats logs how well the caches are doing.
+func (b *Bot) logCacheStats(logf func(format string, args ...any)) {
+	for _, c := range []struct {
+		name  string
+		stats cache.Stats
+	}{
+		{"Search", b.searchCache.Stats()},
+		{"Completion", b.completionCache.Stats()},
+	} {
+		logf("%s cache: %d hits, %d misses (%.0f%% hits), %d evictions, %d entries",
+			c.name, c.stats.Hits, c.stats.Misses, c.stats.HitRate()*100, c.stats.Evictions, c.stats.Entries)
+	}
+}
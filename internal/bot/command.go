@@ -0,0 +1,257 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/charmbracelet/log"
+)
+
+const (
+	categoryAI         = "AI & Knowledge"
+	categoryUtilities  = "Utilities"
+	categoryFun        = "Fun & Social"
+	categoryModeration = "Moderation"
+)
+
+type Command struct {
+	Name        string
+	Aliases     []string
+	Usage       string // Arguments, eg. "<question>"
+	Description string
+	Category    string
+	Permissions int64 // Discord permissions required, moderator roles always qualify
+	Handler     func(c *CommandContext)
+
+	// Slash registers the command as a Discord application command. Option values
+	// are passed to the handler as arguments in the order they are declared.
+	Slash   bool
+	Options []*discordgo.ApplicationCommandOption
+}
+
+// CommandContext describes a single command invocation, either from a prefixed
+// message or from a slash command interaction.
+type CommandContext struct {
+	Session     *discordgo.Session
+	Message     *discordgo.MessageCreate     // Set for prefix commands
+	Interaction *discordgo.InteractionCreate // Set for slash commands
+	Command     *Command
+	Prefix      string
+	Args        []string // Tokenized arguments
+	Raw         string   // Arguments as typed by the user
+
+	GuildID   string
+	ChannelID string
+	Author    *discordgo.User
+	Member    *discordgo.Member
+
+	placeholder *discordgo.Message
+	responded   bool
+}
+
+// RawAfter returns the arguments as typed by the user, without the first n words.
+func (c *CommandContext) RawAfter(n int) string {
+	raw := c.Raw
+	for i := 0; i < n; i++ {
+		raw = strings.TrimLeftFunc(raw, unicode.IsSpace)
+		if end := strings.IndexFunc(raw, unicode.IsSpace); end >= 0 {
+			raw = raw[end:]
+		} else {
+			raw = ""
+		}
+	}
+
+	return strings.TrimSpace(raw)
+}
+
+// Reply sends a new message in response to the command.
+func (c *CommandContext) Reply(content string) {
+	if err := c.send(&discordgo.MessageSend{Content: content}); err != nil {
+		log.Errorf("Failed to reply to command %s: %v", c.Command.Name, err)
+	}
+}
+
+// ReplyUsage tells the user how the command is meant to be invoked.
+func (c *CommandContext) ReplyUsage(example string) {
+	usage := fmt.Sprintf("Usage: `%s%s %s`", c.Prefix, c.Command.Name, c.Command.Usage)
+	if example != "" {
+		usage += fmt.Sprintf(" (eg. %s%s %s)", c.Prefix, c.Command.Name, example)
+	}
+	c.Reply(usage)
+}
+
+// Thinking acknowledges a slow command. Prefix commands get a placeholder message
+// and slash commands a deferred response, both are later replaced with Edit.
+func (c *CommandContext) Thinking() {
+	var err error
+	if c.Interaction != nil {
+		err = c.Session.InteractionRespond(c.Interaction.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		})
+		c.responded = err == nil
+	} else {
+		c.placeholder, err = c.Session.ChannelMessageSend(c.ChannelID, "💭 Thinking...")
+	}
+
+	if err != nil {
+		log.Errorf("Failed to acknowledge command %s: %v", c.Command.Name, err)
+	}
+}
+
+// Edit replaces the placeholder created by Thinking, or sends a new message if there is none.
+func (c *CommandContext) Edit(content string, embed *discordgo.MessageEmbed) error {
+	data := &discordgo.MessageSend{Content: content}
+	if embed != nil {
+		data.Embeds = []*discordgo.MessageEmbed{embed}
+	}
+
+	return c.edit(data)
+}
+
+func (c *CommandContext) edit(data *discordgo.MessageSend) error {
+	if c.Interaction == nil {
+		r := &messageReplier{session: c.Session, channelID: c.ChannelID, placeholder: c.placeholder}
+		return r.edit(data)
+	}
+
+	if !c.responded {
+		return c.send(data)
+	}
+
+	_, err := c.Session.InteractionResponseEdit(c.Interaction.Interaction, &discordgo.WebhookEdit{
+		Content: &data.Content,
+		Embeds:  &data.Embeds,
+		Files:   data.Files,
+	})
+	return err
+}
+
+func (c *CommandContext) send(data *discordgo.MessageSend) error {
+	if c.Interaction == nil {
+		_, err := c.Session.ChannelMessageSendComplex(c.ChannelID, data)
+		return err
+	}
+
+	if !c.responded {
+		c.responded = true
+		return c.Session.InteractionRespond(c.Interaction.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: data.Content,
+				Embeds:  data.Embeds,
+				Files:   data.Files,
+			},
+		})
+	}
+
+	_, err := c.Session.FollowupMessageCreate(c.Interaction.Interaction, true, &discordgo.WebhookParams{
+		Content: data.Content,
+		Embeds:  data.Embeds,
+		Files:   data.Files,
+	})
+	return err
+}
+
+type CommandRegistry struct {
+	commands []*Command
+	lookup   map[string]*Command
+}
+
+func NewCommandRegistry() *CommandRegistry {
+	return &CommandRegistry{
+		commands: []*Command{},
+		lookup:   map[string]*Command{},
+	}
+}
+
+// Register adds a command to the registry. It panics if the name or one of the aliases is already taken.
+func (r *CommandRegistry) Register(cmd *Command) {
+	for _, name := range append([]string{cmd.Name}, cmd.Aliases...) {
+		name = strings.ToLower(name)
+		if _, ok := r.lookup[name]; ok {
+			panic(fmt.Sprintf("command %q registered twice", name))
+		}
+		r.lookup[name] = cmd
+	}
+
+	r.commands = append(r.commands, cmd)
+}
+
+// Lookup returns the command registered under the given name or alias.
+func (r *CommandRegistry) Lookup(name string) (*Command, bool) {
+	cmd, ok := r.lookup[strings.ToLower(name)]
+	return cmd, ok
+}
+
+// Commands returns all commands in registration order.
+func (r *CommandRegistry) Commands() []*Command {
+	return r.commands
+}
+
+// Categories returns the command categories in the order they were first registered.
+func (r *CommandRegistry) Categories() []string {
+	categories := []string{}
+	seen := map[string]bool{}
+	for _, cmd := range r.commands {
+		if !seen[cmd.Category] {
+			seen[cmd.Category] = true
+			categories = append(categories, cmd.Category)
+		}
+	}
+
+	return categories
+}
+
+// tokenize splits arguments on whitespace. Single or double quotes at the start of
+// a token group words until the matching quote, and a backslash escapes the next
+// character. Unterminated quotes are kept literally.
+func tokenize(input string) []string {
+	tokens := []string{}
+	runes := []rune(input)
+
+	for i := 0; i < len(runes); {
+		if unicode.IsSpace(runes[i]) {
+			i++
+			continue
+		}
+
+		if quote := runes[i]; quote == '"' || quote == '\'' {
+			if token, next, ok := readQuoted(runes, i+1, quote); ok {
+				tokens = append(tokens, token)
+				i = next
+				continue
+			}
+		}
+
+		token := &strings.Builder{}
+		for i < len(runes) && !unicode.IsSpace(runes[i]) {
+			if runes[i] == '\\' && i+1 < len(runes) {
+				i++
+			}
+			token.WriteRune(runes[i])
+			i++
+		}
+		tokens = append(tokens, token.String())
+	}
+
+	return tokens
+}
+
+func readQuoted(runes []rune, start int, quote rune) (string, int, bool) {
+	token := &strings.Builder{}
+	for i := start; i < len(runes); i++ {
+		switch {
+		case runes[i] == '\\' && i+1 < len(runes):
+			i++
+			token.WriteRune(runes[i])
+		case runes[i] == quote:
+			return token.String(), i + 1, true
+		default:
+			token.WriteRune(runes[i])
+		}
+	}
+
+	return "", start, false
+}
@@ -9,67 +9,207 @@ import (
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/charmbracelet/log"
-	"wherd.dev/chad/internal/openrouter"
-	"wherd.dev/chad/internal/websearch"
+	"wherd.dev/chad/internal/when"
 )
 
-func (b *Bot) handleCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
-	if strings.HasPrefix(m.Content, "!help") {
-		b.handleHelp(s, m)
-		return
-	}
+func (b *Bot) registerCommands() {
+	b.commands.Register(&Command{
+		Name:        "help",
+		Aliases:     []string{"commands"},
+		Usage:       "[command]",
+		Description: "Show this message or details about a command",
+		Category:    categoryUtilities,
+		Handler:     b.handleHelp,
+	})
 
-	if strings.HasPrefix(m.Content, "!ask ") {
-		b.handleAsk(s, m)
-		return
-	}
+	b.commands.Register(&Command{
+		Name:        "ask",
+		Usage:       "<question>",
+		Description: "Ask the AI",
+		Category:    categoryAI,
+		Handler:     b.handleAsk,
+		Slash:       true,
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "question", Description: "Your question", Required: true},
+		},
+	})
 
-	if strings.HasPrefix(m.Content, "!flip") {
-		b.handleCoinFlip(s, m)
-		return
-	}
+	b.commands.Register(&Command{
+		Name:        "factcheck",
+		Aliases:     []string{"fc"},
+		Usage:       "<claim>",
+		Description: "Verify claims with web search",
+		Category:    categoryAI,
+		Handler:     b.handleFactcheck,
+		Slash:       true,
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "claim", Description: "The claim to verify", Required: true},
+		},
+	})
 
-	if strings.HasPrefix(m.Content, "!roll") {
-		b.handleDiceRoll(s, m)
-		return
-	}
+	b.commands.Register(&Command{
+		Name:        "summary",
+		Aliases:     []string{"memory"},
+		Usage:       "[forget]",
+		Description: "Show what the AI remembers about this channel beyond its latest messages",
+		Category:    categoryAI,
+		Handler:     b.handleSummary,
+	})
+
+	b.commands.Register(&Command{
+		Name:        "usage",
+		Description: "Show what the AI cost in this server today and this month",
+		Category:    categoryAI,
+		Handler:     b.handleUsage,
+	})
+
+	b.commands.Register(&Command{
+		Name:        "remind",
+		Aliases:     []string{"reminder"},
+		Usage:       "<time> <message> | list [channel] | cancel <number> | edit <number> <time> [message] | timezone [zone]",
+		Description: "Set, list or change reminders, once or repeating",
+		Category:    categoryUtilities,
+		Handler:     b.handleRemind,
+		Slash:       true,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type: discordgo.ApplicationCommandOptionSubCommand, Name: "set", Description: "Set a reminder",
+				Options: []*discordgo.ApplicationCommandOption{
+					{Type: discordgo.ApplicationCommandOptionString, Name: "time", Description: "When to remind you (eg. 2h, tomorrow at 9am or every monday 10:00)", Required: true},
+					{Type: discordgo.ApplicationCommandOptionString, Name: "message", Description: "What to remind you about", Required: true},
+				},
+			},
+			{
+				Type: discordgo.ApplicationCommandOptionSubCommand, Name: "list", Description: "List your reminders",
+				Options: []*discordgo.ApplicationCommandOption{
+					{Type: discordgo.ApplicationCommandOptionString, Name: "scope", Description: "Whose reminders", Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "Mine", Value: "mine"},
+						{Name: "Everyone's in this channel (moderators)", Value: "channel"},
+					}},
+				},
+			},
+			{
+				Type: discordgo.ApplicationCommandOptionSubCommand, Name: "cancel", Description: "Cancel a reminder",
+				Options: []*discordgo.ApplicationCommandOption{
+					{Type: discordgo.ApplicationCommandOptionString, Name: "number", Description: "The reminder number from the list", Required: true},
+				},
+			},
+			{
+				Type: discordgo.ApplicationCommandOptionSubCommand, Name: "edit", Description: "Change when a reminder goes off",
+				Options: []*discordgo.ApplicationCommandOption{
+					{Type: discordgo.ApplicationCommandOptionString, Name: "number", Description: "The reminder number from the list", Required: true},
+					{Type: discordgo.ApplicationCommandOptionString, Name: "time", Description: "The new time", Required: true},
+					{Type: discordgo.ApplicationCommandOptionString, Name: "message", Description: "The new message"},
+				},
+			},
+			{
+				Type: discordgo.ApplicationCommandOptionSubCommand, Name: "timezone", Description: "Show or set your time zone",
+				Options: []*discordgo.ApplicationCommandOption{
+					{Type: discordgo.ApplicationCommandOptionString, Name: "zone", Description: "Like Europe/Berlin or America/New_York"},
+				},
+			},
+		},
+	})
+
+	b.commands.Register(&Command{
+		Name:        "flip",
+		Aliases:     []string{"coin"},
+		Description: "Flip a coin",
+		Category:    categoryFun,
+		Handler:     b.handleCoinFlip,
+		Slash:       true,
+	})
+
+	b.commands.Register(&Command{
+		Name:        "roll",
+		Aliases:     []string{"dice"},
+		Usage:       "[dice]",
+		Description: "Roll dice (eg. 2d6 or 20)",
+		Category:    categoryFun,
+		Handler:     b.handleDiceRoll,
+		Slash:       true,
+		Options: []*discordgo.ApplicationCommandOption{
+			{Type: discordgo.ApplicationCommandOptionString, Name: "dice", Description: "Dice to roll (eg. 2d6 or 20)"},
+		},
+	})
+
+	b.commands.Register(&Command{
+		Name:        "settings",
+		Usage:       "[prefix | disable | enable | modrole | welcome | ratelimit] [value]",
+		Description: "View or change server settings",
+		Category:    categoryModeration,
+		Permissions: discordgo.PermissionManageGuild,
+		Handler:     b.handleSettings,
+	})
 
-	if strings.HasPrefix(m.Content, "!remind") {
-		b.handleRemind(s, m)
+	b.commands.Register(&Command{
+		Name:        "ai",
+		Usage:       "[channel] [persona | model | fallbacks | task | temperature | maxtokens | reset] [value]",
+		Description: "View or change the AI persona and model for this server or channel",
+		Category:    categoryModeration,
+		Permissions: discordgo.PermissionManageGuild,
+		Handler:     b.handleAI,
+	})
+}
+
+func (b *Bot) handleCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	prefix := b.guildPrefix(m.GuildID)
+	content := strings.TrimSpace(strings.TrimPrefix(m.Content, prefix))
+
+	name, raw, _ := strings.Cut(content, " ")
+	cmd, ok := b.commands.Lookup(name)
+	if !ok || b.isCommandDisabled(m.GuildID, cmd.Name) {
 		return
 	}
 
-	if strings.HasPrefix(m.Content, "!factcheck ") {
-		b.handleFactcheck(s, m)
+	if cmd.Permissions != 0 && !b.hasPermissions(s, m.GuildID, m.ChannelID, m.Author.ID, m.Member, cmd.Permissions) {
+		s.ChannelMessageSend(m.ChannelID, "❌ You don't have permission to use this command.")
 		return
 	}
+
+	raw = strings.TrimSpace(raw)
+	cmd.Handler(&CommandContext{
+		Session:   s,
+		Message:   m,
+		Command:   cmd,
+		Prefix:    prefix,
+		Args:      tokenize(raw),
+		Raw:       raw,
+		GuildID:   m.GuildID,
+		ChannelID: m.ChannelID,
+		Author:    m.Author,
+		Member:    m.Member,
+	})
 }
 
-func (b *Bot) handleAsk(s *discordgo.Session, m *discordgo.MessageCreate) {
-	m.Content = strings.TrimPrefix(m.Content, "!ask ")
+func (b *Bot) handleAsk(c *CommandContext) {
+	if len(c.Raw) == 0 {
+		c.ReplyUsage("What is the meaning of life?")
+		return
+	}
 
-	if len(m.Content) == 0 {
-		s.ChannelMessageSend(m.ChannelID, "Usage: `!ask <your question>` (eg. !ask What is the meaning of life?)")
+	if message, over := b.overBudget(c.GuildID); over {
+		c.Reply(message)
 		return
 	}
 
-	res, err := s.ChannelMessageSend(m.ChannelID, "💭 Thinking...")
+	c.Thinking()
 
-	b.mutex.RLock()
-	o := &openrouter.OpenRouter{
-		Key:                  b.config.OpenRouter.Key,
-		MaxMessagesInContext: b.config.OpenRouter.MaxMessagesInContext,
-		Model:                b.config.OpenRouter.Model,
-	}
+	o := b.newClient(c.GuildID, c.ChannelID, c.Author.ID, taskAsk)
 
 	req := o.NewRequest()
-	req.AddMessages(b.messageHistory[m.ChannelID])
-	b.mutex.RUnlock()
+	b.addSummary(req, c.ChannelID)
+	req.Tools = b.tools.Definitions() // Before the context, which leaves room for them
+	b.addContext(o, req, c.GuildID, c.ChannelID)
 
-	response, err := o.Send(req)
+	response, err := b.completeWithTools(o, req, func(content string) {
+		if err := c.Edit(content, nil); err != nil {
+			log.Errorf("Failed to update message: %v", err)
+		}
+	})
 	if err != nil {
 		log.Errorf("Failed to send request: %v", err)
-		if err = maybeEditMessage(s, m.ChannelID, res, "❌ Sorry I'm unable to think right now.", nil); err != nil {
+		if err = c.Edit("❌ Sorry I'm unable to think right now.", nil); err != nil {
 			log.Errorf("Failed to send error message: %v", err)
 		}
 		return
@@ -77,7 +217,7 @@ func (b *Bot) handleAsk(s *discordgo.Session, m *discordgo.MessageCreate) {
 
 	if len(response.Choices) == 0 {
 		log.Error("No choices in response")
-		if err = maybeEditMessage(s, m.ChannelID, res, "❌ Sorry I'm unable to think right now.", nil); err != nil {
+		if err = c.Edit("❌ Sorry I'm unable to think right now.", nil); err != nil {
 			log.Errorf("Failed to send error message: %v", err)
 		}
 		return
@@ -95,156 +235,57 @@ func (b *Bot) handleAsk(s *discordgo.Session, m *discordgo.MessageCreate) {
 	})
 	b.mutex.RUnlock()
 
-	if err = maybeEditMessage(s, m.ChannelID, res, content, nil); err != nil {
+	if err = b.deliverReply(c, content); err != nil {
 		log.Errorf("Failed to send message: %v", err)
 	}
 }
 
-func (b *Bot) handleFactcheck(s *discordgo.Session, m *discordgo.MessageCreate) {
-	m.Content = strings.TrimPrefix(m.Content, "!factcheck ")
-
-	if len(m.Content) == 0 {
-		s.ChannelMessageSend(m.ChannelID, "Usage: `!factcheck <claim>`")
-		return
-	}
-
-	res, err := s.ChannelMessageSend(m.ChannelID, "💭 Thinking...")
-
-	b.mutex.RLock()
-	searchResults, err := websearch.Search(b.config.SearchApiKey, "fact check "+m.Content)
-	b.mutex.RUnlock()
-
-	if err != nil {
-		log.Printf("Web search error: %v", err)
-		if err = maybeEditMessage(s, m.ChannelID, res, "❌ Failed to search for information. Please try again later.", nil); err != nil {
-			log.Errorf("Failed to send error message: %v", err)
-		}
-		return
-	}
-
-	searchContext := &strings.Builder{}
-	for _, result := range searchResults {
-		searchContext.WriteString(fmt.Sprintf("Title: %s, URL: %s, Content: %s\n", result.Title, result.URL, result.Description))
-	}
-
-	prompt := fmt.Sprintf(`Fact-check this claim using the search results below.
-
-CLAIM: "%s"
-
-SEARCH RESULTS:
-%s
-
-Respond with:
-
-VERDICT: [True/False/Partially True/Unclear] - one sentence why
-
-EVIDENCE: 
-- Supporting: [specific quotes/data from sources]
-- Contradicting: [specific quotes/data from sources]
-
-CONTEXT: [missing context that changes the claim's validity]
-
-CONFIDENCE: [High/Medium/Low] based on source quality and consensus
-
-Rules:
-- Quote exact evidence, don't paraphrase
-- Name the source for each piece of evidence
-- If sources conflict, show both sides
-- "Unclear" if evidence is insufficient
-- Skip sections if not applicable (e.g., no contradicting evidence)`, m.Content, searchContext)
-
-	b.mutex.RLock()
-	o := &openrouter.OpenRouter{
-		Key:                  b.config.OpenRouter.Key,
-		MaxMessagesInContext: b.config.OpenRouter.MaxMessagesInContext,
-		Model:                b.config.OpenRouter.Model,
-	}
-
-	req := o.NewRequest()
-	req.AddMessage("user", prompt)
-	b.mutex.RUnlock()
-
-	response, err := o.Send(req)
-	if err != nil || len(response.Choices) == 0 {
-		log.Errorf("Fact-check AI error: %v", err)
-		if err = maybeEditMessage(s, m.ChannelID, res, "❌ Failed to analyze the fact-check. Please try again later.", nil); err != nil {
-			log.Errorf("Failed to send error message: %v", err)
-		}
-		return
+func (b *Bot) handleCoinFlip(c *CommandContext) {
+	result := "🪙 Heads"
+	if rand.Float32() < 0.5 {
+		result = "🎯 Tails"
 	}
 
-	content := response.Choices[0].Message.Content
-
-	// Determine verdict color
-	verdictColor := 0x95a5a6 // Gray default
-	if strings.Contains(strings.ToLower(content), "verdict: true") {
-		verdictColor = 0x27ae60 // Green
-	} else if strings.Contains(strings.ToLower(content), "verdict: false") {
-		verdictColor = 0xe74c3c // Red
-	} else if strings.Contains(strings.ToLower(content), "partially true") {
-		verdictColor = 0xf39c12 // Orange
-	}
+	c.Reply(result)
+}
 
-	// Build source list from search results
-	sources := ""
-	for i, result := range searchResults {
-		if i >= 3 { // Limit to top 3 sources
-			break
-		}
-		sources += fmt.Sprintf("• [%s](%s)\n", result.Title, result.URL)
-	}
+// Bounds of a dice roll, rand.IntN panics for fewer than one side.
+const (
+	maxDice  = 10
+	maxSides = 100
+)
 
-	embed := &discordgo.MessageEmbed{
-		Title:       "🔍 Fact Check Analysis",
-		Description: fmt.Sprintf("**Claim:** %s\n\n%s", m.Content, content),
-		Color:       verdictColor,
-		Fields: []*discordgo.MessageEmbedField{
-			{
-				Name:   "📚 Sources Checked",
-				Value:  sources,
-				Inline: false,
-			},
-		},
-		Footer: &discordgo.MessageEmbedFooter{
-			Text: fmt.Sprintf("Fact-checked by %s • Always verify with multiple sources", m.Author.Username),
-		},
-		Timestamp: time.Now().Format(time.RFC3339),
+// parseDice reads dice like "2d6", "d20" or "20", a single die when the count is left out.
+func parseDice(arg string) (count int, sides int, ok bool) {
+	countArg, sidesArg, found := strings.Cut(strings.ToLower(arg), "d")
+	if !found {
+		countArg, sidesArg = "1", countArg
+	} else if countArg == "" {
+		countArg = "1"
 	}
 
-	if err = maybeEditMessage(s, m.ChannelID, res, "", embed); err != nil {
-		log.Errorf("Failed to send error message: %v", err)
+	count, err := strconv.Atoi(countArg)
+	if err != nil || count < 1 || count > maxDice {
+		return 0, 0, false
 	}
-}
 
-func (b *Bot) handleCoinFlip(s *discordgo.Session, m *discordgo.MessageCreate) {
-	result := "🪙 Heads"
-	if rand.Float32() < 0.5 {
-		result = "🎯 Tails"
+	sides, err = strconv.Atoi(sidesArg)
+	if err != nil || sides < 1 || sides > maxSides {
+		return 0, 0, false
 	}
 
-	s.ChannelMessageSend(m.ChannelID, result)
+	return count, sides, true
 }
 
-func (b *Bot) handleDiceRoll(s *discordgo.Session, m *discordgo.MessageCreate) {
-	m.Content = strings.TrimPrefix(m.Content, "!roll ")
-
+func (b *Bot) handleDiceRoll(c *CommandContext) {
 	sides := 6
 	count := 1
 
-	if len(m.Content) > 0 {
-		arg := m.Content
-		if strings.Contains(arg, "d") {
-			parts := strings.Split(arg, "d")
-			if len(parts) == 2 {
-				if c, err := strconv.Atoi(parts[0]); err == nil && c <= 10 {
-					count = c
-				}
-				if s, err := strconv.Atoi(parts[1]); err == nil && s <= 100 {
-					sides = s
-				}
-			}
-		} else if s, err := strconv.Atoi(arg); err == nil && s <= 100 {
-			sides = s
+	if len(c.Args) > 0 {
+		var ok bool
+		if count, sides, ok = parseDice(c.Args[0]); !ok {
+			c.Reply(fmt.Sprintf("❌ Roll 1 to %d dice with 1 to %d sides, like `2d6` or `20`.", maxDice, maxSides))
+			return
 		}
 	}
 
@@ -262,79 +303,157 @@ func (b *Bot) handleDiceRoll(s *discordgo.Session, m *discordgo.MessageCreate) {
 		rollsStr[i] = strconv.Itoa(roll)
 	}
 
-	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("🎲 Rolled %dd%d: %s (Total: %d)", count, sides, strings.Join(rollsStr, ", "), total))
+	c.Reply(fmt.Sprintf("🎲 Rolled %dd%d: %s (Total: %d)", count, sides, strings.Join(rollsStr, ", "), total))
 }
 
-func (b *Bot) handleRemind(s *discordgo.Session, m *discordgo.MessageCreate) {
-	args := strings.Split(m.Content, " ")
-	if len(args) < 3 {
-		s.ChannelMessageSend(m.ChannelID, "Usage: `!remind 5m Take a break` or `!remind 2h Meeting with team`")
-		return
+func (b *Bot) handleRemind(c *CommandContext) {
+	args := c.Args
+	if len(args) > 0 {
+		switch strings.ToLower(args[0]) {
+		case "timezone":
+			b.handleTimeZone(c)
+			return
+		case "list":
+			b.handleReminderList(c)
+			return
+		case "cancel":
+			b.handleReminderCancel(c)
+			return
+		case "edit":
+			b.handleReminderEdit(c)
+			return
+		case "set":
+			args = args[1:]
+		}
 	}
 
-	duration, err := time.ParseDuration(args[1])
-	if err != nil || duration.Minutes() < 1 {
-		s.ChannelMessageSend(m.ChannelID, "Invalid time format. Use: 5m, 2h, 1d (minutes, hours, days)")
+	if len(args) < 2 {
+		c.ReplyUsage("tomorrow at 9am Take a break")
 		return
 	}
 
-	reminderText := strings.Join(args[2:], " ")
-	reminderTime := time.Now().Add(duration)
-
-	// Generate unique ID with incremental counter
-	b.mutex.Lock()
-	b.reminderCounter++
-	reminderID := fmt.Sprintf("%s_%d", m.Author.ID, b.reminderCounter)
-	b.mutex.Unlock()
+	loc := b.userLocation(c.Author.ID)
+	result, reminderText, ok := b.parseReminderTime(c, args, loc)
+	if !ok {
+		return
+	} else if reminderText == "" {
+		c.ReplyUsage("tomorrow at 9am Take a break")
+		return
+	}
 
 	reminder := &Reminder{
-		ID:        reminderID,
 		Message:   reminderText,
-		Time:      reminderTime.Unix(),
-		ChannelID: m.ChannelID,
-		UserID:    m.Author.ID,
+		Time:      result.Time.Unix(),
+		ChannelID: c.ChannelID,
+		UserID:    c.Author.ID,
+		TimeZone:  loc.String(),
+	}
+	if result.Rule != nil {
+		reminder.Recurrence = result.Rule.String()
+	}
+
+	b.addReminder(reminder)
+
+	rule := result.Rule
+	if rule == nil {
+		c.Reply(fmt.Sprintf("<@!%s> I'll remind you <t:%d:R> (<t:%d:f>) about: \"%s\" `#%s`", c.Author.ID, reminder.Time, reminder.Time, reminderText, reminder.number()))
+		return
+	}
+
+	schedule := rule.Describe()
+	if rule.Freq != when.Minutely && rule.Freq != when.Hourly {
+		schedule += fmt.Sprintf(" at %s (%s)", result.Time.Format("15:04"), loc)
+	}
+	c.Reply(fmt.Sprintf("<@!%s> I'll remind you %s about: \"%s\", starting <t:%d:f> `#%s`", c.Author.ID, schedule, reminderText, reminder.Time, reminder.number()))
+}
+
+// parseReminderTime reads a time expression followed by the reminder text, and replies when it is invalid.
+func (b *Bot) parseReminderTime(c *CommandContext, args []string, loc *time.Location) (*when.Result, string, bool) {
+	result, message, err := splitReminderTime(args, c.Interaction != nil, time.Now().In(loc))
+	if err != nil {
+		c.Reply(fmt.Sprintf("❌ %s. Try `5m`, `1d`, `tomorrow at 9am`, `friday 17:30`, `2026-11-01 14:00` or `every monday 10:00`.", err))
+		return nil, "", false
+	}
+
+	if result.Rule == nil && time.Until(result.Time) < time.Minute {
+		c.Reply("❌ Reminders need to be at least a minute away.")
+		return nil, "", false
+	} else if result.Rule != nil && result.Rule.Period() < minReminderInterval {
+		c.Reply(fmt.Sprintf("❌ Reminders can repeat at most every %s.", minReminderInterval))
+		return nil, "", false
 	}
 
-	b.mutex.Lock()
-	b.reminders = append(b.reminders, reminder)
-	b.scheduleReminder(reminder)
-	b.mutex.Unlock()
+	return result, strings.Join(message, " "), true
+}
+
+// splitReminderTime parses the time expression at the start of the arguments and returns the
+// words after it. A time that comes as a single quoted argument or slash command option has
+// to be understood in full, the message doesn't start inside it.
+func splitReminderTime(args []string, slash bool, now time.Time) (*when.Result, []string, error) {
+	words, message := args, []string(nil)
+	if slash || strings.Contains(args[0], " ") {
+		words, message = strings.Fields(args[0]), args[1:]
+	}
 
-	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("<@!%s> I'll remind you in %s about: \"%s\"", m.Author.ID, args[1], reminderText))
+	result, n, err := when.Parse(words, now)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if message == nil {
+		return result, words[n:], nil
+	} else if n < len(words) {
+		return nil, nil, fmt.Errorf("I don't understand when %q is", strings.Join(words[n:], " "))
+	}
+
+	return result, message, nil
 }
 
-func (b *Bot) handleHelp(s *discordgo.Session, m *discordgo.MessageCreate) {
-	help := `I can help you with the following:
+func (b *Bot) handleHelp(c *CommandContext) {
+	if len(c.Args) > 0 {
+		cmd, ok := b.commands.Lookup(strings.TrimPrefix(c.Args[0], c.Prefix))
+		if !ok {
+			c.Reply(fmt.Sprintf("❌ Unknown command `%s`", c.Args[0]))
+			return
+		}
+
+		help := fmt.Sprintf("**%s%s** - %s\nUsage: `%s%s %s`", c.Prefix, cmd.Name, cmd.Description, c.Prefix, cmd.Name, cmd.Usage)
+		if len(cmd.Aliases) > 0 {
+			help += fmt.Sprintf("\nAliases: `%s`", strings.Join(cmd.Aliases, "`, `"))
+		}
 
-  **AI & Knowledge**
-  !ask <question> - Ask the AI
-  !factcheck <claim> - Verify claims with web search
+		c.Reply(help)
+		return
+	}
 
-  **Utilities**  
-  !remind 5m <message> - Set reminder
+	help := &strings.Builder{}
+	help.WriteString("I can help you with the following:\n")
 
-  **Fun & Social**
-  !flip - Flip a coin
-  !roll [dice] - Roll dice (eg. 2d6 or 20)
+	for _, category := range b.commands.Categories() {
+		fmt.Fprintf(help, "\n  **%s**\n", category)
+		for _, cmd := range b.commands.Commands() {
+			if cmd.Category != category || b.isCommandDisabled(c.GuildID, cmd.Name) {
+				continue
+			}
 
-  You can also mention me to get my attention.`
+			usage := ""
+			if cmd.Usage != "" {
+				usage = " " + cmd.Usage
+			}
+			fmt.Fprintf(help, "  %s%s%s - %s\n", c.Prefix, cmd.Name, usage, cmd.Description)
+		}
+	}
 
-	s.ChannelMessageSend(m.ChannelID, help)
+	help.WriteString("\n  You can also mention me to get my attention.")
+	c.Reply(help.String())
 }
 
 func maybeEditMessage(s *discordgo.Session, channelID string, m *discordgo.Message, content string, embed *discordgo.MessageEmbed) error {
-	var err error
-	if m != nil {
-		if embed != nil {
-			_, err = s.ChannelMessageEditEmbed(m.ChannelID, m.ID, embed)
-		} else {
-			_, err = s.ChannelMessageEdit(m.ChannelID, m.ID, content)
-		}
-	} else if embed != nil {
-		_, err = s.ChannelMessageSendEmbed(channelID, embed)
-	} else {
-		_, err = s.ChannelMessageSend(channelID, content)
+	data := &discordgo.MessageSend{Content: content}
+	if embed != nil {
+		data.Embeds = []*discordgo.MessageEmbed{embed}
 	}
 
-	return err
+	r := &messageReplier{session: s, channelID: channelID, placeholder: m}
+	return r.edit(data)
 }
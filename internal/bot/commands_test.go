@@ -0,0 +1,83 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseDice(t *testing.T) {
+	tests := []struct {
+		arg   string
+		count int
+		sides int
+		ok    bool
+	}{
+		{"2d6", 2, 6, true},
+		{"2D6", 2, 6, true},
+		{"d20", 1, 20, true},
+		{"20", 1, 20, true},
+		{"10d100", 10, 100, true},
+		{"1d1", 1, 1, true},
+		{"0", 0, 0, false},
+		{"-5", 0, 0, false},
+		{"d0", 0, 0, false},
+		{"0d6", 0, 0, false},
+		{"-2d6", 0, 0, false},
+		{"2d-6", 0, 0, false},
+		{"11d6", 0, 0, false},
+		{"2d101", 0, 0, false},
+		{"2d", 0, 0, false},
+		{"2d6d6", 0, 0, false},
+		{"dice", 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		count, sides, ok := parseDice(tt.arg)
+		if count != tt.count || sides != tt.sides || ok != tt.ok {
+			t.Errorf("parseDice(%q) = %d, %d, %v, want %d, %d, %v", tt.arg, count, sides, ok, tt.count, tt.sides, tt.ok)
+		}
+	}
+}
+
+func TestSplitReminderTime(t *testing.T) {
+	now := time.Date(2026, 3, 25, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		args    []string
+		slash   bool
+		time    time.Time
+		message string
+		ok      bool
+	}{
+		{"prefix", []string{"in", "5m", "call", "mom"}, false, now.Add(5 * time.Minute), "call mom", true},
+		{"quoted", []string{"tomorrow at 9am", "call", "mom"}, false, time.Date(2026, 3, 26, 9, 0, 0, 0, time.UTC), "call mom", true},
+		{"quoted unknown", []string{"tomorrow at lunch", "call", "mom"}, false, time.Time{}, "", false},
+		{"slash", []string{"5m", "call mom"}, true, now.Add(5 * time.Minute), "call mom", true},
+		{"slash phrase", []string{"tomorrow at 9am", "call mom"}, true, time.Date(2026, 3, 26, 9, 0, 0, 0, time.UTC), "call mom", true},
+		{"slash without message", []string{"5m"}, true, now.Add(5 * time.Minute), "", true},
+		{"slash incomplete", []string{"at", "5 call mom"}, true, time.Time{}, "", false},
+		{"slash trailing words", []string{"5m call", "mom"}, true, time.Time{}, "", false},
+		{"slash empty", []string{"", "call mom"}, true, time.Time{}, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, message, err := splitReminderTime(tt.args, tt.slash, now)
+			if (err == nil) != tt.ok {
+				t.Fatalf("splitReminderTime(%q) error = %v, want ok %v", tt.args, err, tt.ok)
+			}
+			if err != nil {
+				return
+			}
+
+			if !result.Time.Equal(tt.time) {
+				t.Errorf("splitReminderTime(%q) time = %v, want %v", tt.args, result.Time, tt.time)
+			}
+			if got := strings.Join(message, " "); got != tt.message {
+				t.Errorf("splitReminderTime(%q) message = %q, want %q", tt.args, got, tt.message)
+			}
+		})
+	}
+}
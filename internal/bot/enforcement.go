@@ -0,0 +1,169 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/charmbracelet/log"
+	"wherd.dev/chad/internal/config"
+	"wherd.dev/chad/internal/ratelimit"
+)
+
+// penalty is what a member gets for a rate limited action.
+type penalty int
+
+const (
+	penaltyNone     penalty = iota
+	penaltyWait             // A guild or channel budget ran out, nobody is at fault
+	penaltyWarn             // First strikes
+	penaltyCooldown         // The bot ignores the member's commands for a while
+	penaltyCooling          // The member is still cooling down and was already told
+	penaltyTimeout          // The member got a Discord timeout
+)
+
+// maxTimeout is the longest timeout Discord allows.
+const maxTimeout = 28 * 24 * time.Hour
+
+// Offense tracks a member going over their rate limit, keyed by "<guild>:<user>".
+type Offense struct {
+	Strikes       []int64 `json:"strikes"` // Unix time of every strike within the strike window
+	CooldownUntil int64   `json:"cooldown_until,omitempty"`
+}
+
+// enforcementPolicy returns the guild's enforcement policy, falling back to the configured one.
+func (b *Bot) enforcementPolicy(guildID string) config.Enforcement {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	if settings, ok := b.guildSettings[guildID]; ok && settings.Enforcement != nil {
+		return *settings.Enforcement
+	}
+
+	return b.config.RateLimit.Enforcement
+}
+
+// enforceRateLimit charges an action and escalates against members who keep exceeding their limit.
+// It returns the penalty and a message explaining it.
+func (b *Bot) enforceRateLimit(s *discordgo.Session, guildID string, channelID string, member *discordgo.Member, user *discordgo.User, action string) (penalty, string) {
+	key := guildID + ":" + user.ID
+	now := time.Now().Unix()
+
+	b.mutex.RLock()
+	var cooldown int64
+	if offense, ok := b.offenses[key]; ok {
+		cooldown = offense.CooldownUntil - now
+	}
+	b.mutex.RUnlock()
+
+	if cooldown > 0 {
+		return penaltyCooling, fmt.Sprintf("🧊 You're cooling down, I'll listen to you again in %s.", time.Duration(cooldown)*time.Second)
+	}
+
+	result := b.checkRateLimit(guildID, channelID, member, user.ID, action)
+	if result.Allowed {
+		return penaltyNone, ""
+	} else if result.Scope != ratelimit.User {
+		return penaltyWait, rateLimitMessage(result)
+	}
+
+	policy := b.enforcementPolicy(guildID)
+
+	b.mutex.Lock()
+	offense := b.offenses[key]
+	if offense == nil {
+		offense = &Offense{}
+		b.offenses[key] = offense
+	}
+	offense.Strikes = append(recentStrikes(offense.Strikes, now-policy.StrikeWindow), now)
+	strikes := len(offense.Strikes)
+
+	p := penaltyWarn
+	switch {
+	case guildID != "" && policy.TimeoutAfter > 0 && strikes >= policy.TimeoutAfter:
+		p = penaltyTimeout
+	case policy.CooldownAfter > 0 && strikes >= policy.CooldownAfter:
+		p = penaltyCooldown
+		offense.CooldownUntil = now + policy.Cooldown
+	}
+	b.dirty.offenses = true
+	b.mutex.Unlock()
+
+	switch p {
+	case penaltyTimeout:
+		// Policies from the config aren't checked against the limit, Discord rejects longer timeouts
+		timeout := maxTimeout
+		if policy.Timeout < int64(maxTimeout/time.Second) {
+			timeout = time.Duration(policy.Timeout) * time.Second
+		}
+		until := time.Now().Add(timeout)
+		reason := fmt.Sprintf("Exceeded the bot rate limit %d times within %s", strikes, time.Duration(policy.StrikeWindow)*time.Second)
+		if err := s.GuildMemberTimeout(guildID, user.ID, &until, discordgo.WithAuditLogReason(reason)); err != nil {
+			log.Errorf("Failed to timeout user %s: %v", user.Username, err)
+			return penaltyWarn, rateLimitMessage(result)
+		}
+
+		log.Infof("Timed out %s in guild %s for %s", user.Username, guildID, timeout)
+		return p, fmt.Sprintf("🔇 %s has been timed out for %s for repeatedly exceeding the rate limit.", user.Mention(), timeout)
+
+	case penaltyCooldown:
+		return p, fmt.Sprintf("🧊 %s, slow down! I'll ignore your commands for %s.", user.Mention(), time.Duration(policy.Cooldown)*time.Second)
+	}
+
+	return p, rateLimitMessage(result)
+}
+
+// notifyPenalty lets the author of a rate limited message know, without adding to the noise.
+func (b *Bot) notifyPenalty(s *discordgo.Session, m *discordgo.MessageCreate, p penalty, notice string) {
+	switch p {
+	case penaltyWait:
+		if err := s.MessageReactionAdd(m.ChannelID, m.ID, "⏳"); err != nil {
+			log.Errorf("Failed to add wait reaction: %v", err)
+		}
+
+	case penaltyWarn:
+		if err := s.MessageReactionAdd(m.ChannelID, m.ID, "⏰"); err != nil {
+			log.Errorf("Failed to add warning reaction: %v", err)
+		}
+
+	case penaltyCooldown, penaltyTimeout:
+		if _, err := s.ChannelMessageSend(m.ChannelID, notice); err != nil {
+			log.Errorf("Failed to send rate limit notice: %v", err)
+		}
+	}
+}
+
+// recentStrikes drops the strikes older than the cutoff.
+func recentStrikes(strikes []int64, cutoff int64) []int64 {
+	recent := strikes[:0]
+	for _, strike := range strikes {
+		if strike > cutoff {
+			recent = append(recent, strike)
+		}
+	}
+
+	return recent
+}
+
+// pruneOffenses forgets offenses with no strikes left in their window. The caller must hold the write lock.
+func (b *Bot) pruneOffenses() {
+	now := time.Now().Unix()
+	for key, offense := range b.offenses {
+		window := b.config.RateLimit.Enforcement.StrikeWindow
+		guildID, _, _ := strings.Cut(key, ":")
+		if settings, ok := b.guildSettings[guildID]; ok && settings.Enforcement != nil {
+			window = settings.Enforcement.StrikeWindow
+		}
+
+		if strikes := recentStrikes(offense.Strikes, now-window); len(strikes) != len(offense.Strikes) {
+			offense.Strikes = strikes
+			b.dirty.offenses = true
+		}
+
+		if len(offense.Strikes) == 0 && offense.CooldownUntil <= now {
+			delete(b.offenses, key)
+			b.dirty.offenses = true
+		}
+	}
+}
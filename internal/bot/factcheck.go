@@ -0,0 +1,286 @@
+package bot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/charmbracelet/log"
+	"wherd.dev/chad/internal/openrouter"
+	"wherd.dev/chad/internal/websearch"
+)
+
+// embedFieldLimit is the most characters Discord shows in an embed field.
+const embedFieldLimit = 1024
+
+// factCheck is the verdict the model answers a fact-check with.
+type factCheck struct {
+	Verdict    string         `json:"verdict"`
+	Summary    string         `json:"summary"`
+	Confidence string         `json:"confidence"`
+	Evidence   []factEvidence `json:"evidence"`
+	Context    string         `json:"context"`
+}
+
+// factEvidence is a piece of evidence quoted from the search results, which are numbered from 1.
+type factEvidence struct {
+	Quote    string `json:"quote"`
+	Supports bool   `json:"supports"`
+	Sources  []int  `json:"sources"`
+}
+
+var verdicts = map[string]struct {
+	label string
+	color int
+}{
+	"true":           {"✅ True", 0x27ae60},
+	"false":          {"❌ False", 0xe74c3c},
+	"partially_true": {"⚠️ Partially true", 0xf39c12},
+	"unclear":        {"❔ Unclear", 0x95a5a6},
+}
+
+var confidences = map[string]string{
+	"high":   "High",
+	"medium": "Medium",
+	"low":    "Low",
+}
+
+var factCheckSchema = openrouter.Object().
+	RequiredProperty("verdict", openrouter.String("true, false, partially_true, or unclear if the evidence is insufficient").
+		OneOf("true", "false", "partially_true", "unclear")).
+	RequiredProperty("summary", openrouter.String("One sentence explaining the verdict")).
+	RequiredProperty("confidence", openrouter.String("Based on source quality and consensus").OneOf("high", "medium", "low")).
+	RequiredProperty("evidence", openrouter.Array(openrouter.Object().
+		RequiredProperty("quote", openrouter.String("Exact quote or data from a source, not paraphrased")).
+		RequiredProperty("supports", openrouter.Boolean("Whether it supports the claim, false when it contradicts it")).
+		RequiredProperty("sources", openrouter.Array(openrouter.Integer("Search result number"), "The search results it is from")),
+		"Evidence for and against the claim")).
+	Property("context", openrouter.String("Missing context that changes the claim's validity, empty if there is none"))
+
+func (b *Bot) handleFactcheck(c *CommandContext) {
+	claim := c.Raw
+	if len(claim) == 0 {
+		c.ReplyUsage("The Great Wall of China is visible from space")
+		return
+	}
+
+	if message, over := b.overBudget(c.GuildID); over {
+		c.Reply(message)
+		return
+	}
+
+	c.Thinking()
+
+	searchResults, err := b.webSearch(b.ctx, "fact check "+claim)
+	if err != nil {
+		log.Printf("Web search error: %v", err)
+		if err = c.Edit("❌ Failed to search for information. Please try again later.", nil); err != nil {
+			log.Errorf("Failed to send error message: %v", err)
+		}
+		return
+	}
+
+	searchContext := &strings.Builder{}
+	for i, result := range searchResults {
+		searchContext.WriteString(fmt.Sprintf("[%d] Title: %s, URL: %s, Content: %s\n", i+1, result.Title, result.URL, result.Description))
+		for _, snippet := range result.ExtraSnippets {
+			searchContext.WriteString(fmt.Sprintf("Excerpt: %s\n", snippet))
+		}
+		if result.Content != "" {
+			searchContext.WriteString(fmt.Sprintf("Page text:\n%s\n", result.Content))
+		}
+		searchContext.WriteString("\n")
+	}
+
+	prompt := fmt.Sprintf(`Fact-check this claim using the numbered search results below.
+
+CLAIM: "%s"
+
+SEARCH RESULTS:
+%s
+
+Answer with a JSON object with these fields:
+- verdict: "true", "false", "partially_true" or "unclear"
+- summary: one sentence why
+- confidence: "high", "medium" or "low", based on source quality and consensus
+- evidence: a list of {"quote", "supports", "sources"}, where sources are the numbers of the search results the quote is from
+- context: missing context that changes the claim's validity, or ""
+
+Rules:
+- Quote exact evidence, don't paraphrase
+- Cite the search results every piece of evidence is from
+- If sources conflict, include both sides
+- "unclear" if evidence is insufficient`, claim, searchContext)
+
+	o := b.newClient(c.GuildID, c.ChannelID, c.Author.ID, taskFactcheck)
+
+	req := o.NewRequest()
+	req.AddMessage("user", prompt)
+	req.ResponseFormat = openrouter.JSONResponse("fact_check", factCheckSchema)
+
+	response, err := o.Send(b.ctx, req)
+	if errors.Is(err, openrouter.ErrBadRequest) {
+		// Not every model supports structured output, the prompt asks for JSON anyway
+		log.Warnf("Fact-check with structured output failed, retrying without: %v", err)
+		req.ResponseFormat = nil
+		response, err = o.Send(b.ctx, req)
+	}
+
+	if err != nil || len(response.Choices) == 0 {
+		log.Errorf("Fact-check AI error: %v", err)
+		if err = c.Edit("❌ Failed to analyze the fact-check. Please try again later.", nil); err != nil {
+			log.Errorf("Failed to send error message: %v", err)
+		}
+		return
+	}
+
+	content := response.Choices[0].Message.Content
+
+	var embed *discordgo.MessageEmbed
+	if result, ok := parseFactCheck(content); ok {
+		embed = factCheckEmbed(claim, result, searchResults)
+	} else {
+		log.Warnf("Could not parse fact-check, showing it as text: %s", truncate(content, 200))
+		embed = &discordgo.MessageEmbed{
+			Description: fmt.Sprintf("**Claim:** %s\n\n%s", claim, content),
+			Color:       verdicts["unclear"].color,
+		}
+		if sources := sourceList(searchResults, nil); sources != "" {
+			embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "📚 Sources Checked", Value: sources})
+		}
+	}
+
+	embed.Title = "🔍 Fact Check Analysis"
+	embed.Footer = &discordgo.MessageEmbedFooter{
+		Text: fmt.Sprintf("Fact-checked by %s • Always verify with multiple sources", c.Author.Username),
+	}
+	embed.Timestamp = time.Now().Format(time.RFC3339)
+
+	if err = deliverEmbed(c, embed); err != nil {
+		log.Errorf("Failed to send error message: %v", err)
+	}
+}
+
+// parseFactCheck reads the model's answer, which may be wrapped in a code block or surrounded by text.
+func parseFactCheck(content string) (*factCheck, bool) {
+	start, end := strings.Index(content, "{"), strings.LastIndex(content, "}")
+	if start < 0 || end < start {
+		return nil, false
+	}
+
+	result := &factCheck{}
+	if err := json.Unmarshal([]byte(content[start:end+1]), result); err != nil {
+		return nil, false
+	}
+
+	result.Verdict = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(result.Verdict), " ", "_"))
+	if _, ok := verdicts[result.Verdict]; !ok {
+		return nil, false
+	}
+
+	return result, true
+}
+
+// factCheckEmbed renders a verdict with its evidence, citing the search results as numbered footnotes.
+func factCheckEmbed(claim string, result *factCheck, results []*websearch.WebResult) *discordgo.MessageEmbed {
+	verdict := verdicts[result.Verdict]
+	embed := &discordgo.MessageEmbed{
+		Description: fmt.Sprintf("**Claim:** %s\n\n%s", claim, result.Summary),
+		Color:       verdict.color,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Verdict", Value: verdict.label, Inline: true},
+		},
+	}
+
+	if confidence, ok := confidences[strings.ToLower(strings.TrimSpace(result.Confidence))]; ok {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Confidence", Value: confidence, Inline: true})
+	}
+
+	cited := []int{}
+	supporting, contradicting := []string{}, []string{}
+	for _, evidence := range result.Evidence {
+		if strings.TrimSpace(evidence.Quote) == "" {
+			continue
+		}
+
+		line := fmt.Sprintf("• “%s”", truncate(strings.TrimSpace(evidence.Quote), 300))
+		for _, n := range evidence.Sources {
+			if n < 1 || n > len(results) {
+				continue
+			}
+
+			line += fmt.Sprintf(" [[%d]](%s)", n, results[n-1].URL)
+			if !slices.Contains(cited, n) {
+				cited = append(cited, n)
+			}
+		}
+
+		if evidence.Supports {
+			supporting = append(supporting, line)
+		} else {
+			contradicting = append(contradicting, line)
+		}
+	}
+
+	if value := fitLines(supporting, embedFieldLimit); value != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "👍 Supporting", Value: value})
+	}
+	if value := fitLines(contradicting, embedFieldLimit); value != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "👎 Contradicting", Value: value})
+	}
+	if context := strings.TrimSpace(result.Context); context != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "💡 Context", Value: truncate(context, embedFieldLimit)})
+	}
+
+	slices.Sort(cited)
+	if sources := sourceList(results, cited); sources != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "📚 Sources", Value: sources})
+	}
+
+	return embed
+}
+
+// sourceList numbers the cited search results, or lists the top three when none were cited.
+func sourceList(results []*websearch.WebResult, cited []int) string {
+	if len(cited) == 0 {
+		for i := range min(len(results), 3) {
+			cited = append(cited, i+1)
+		}
+	}
+
+	lines := make([]string, len(cited))
+	for i, n := range cited {
+		result := results[n-1]
+		lines[i] = fmt.Sprintf("%d. [%s](%s)", n, truncate(result.Title, 100), result.URL)
+	}
+
+	return fitLines(lines, embedFieldLimit)
+}
+
+// fitLines joins as many lines as fit in the limit, saying how many were left out.
+func fitLines(lines []string, limit int) string {
+	text := &strings.Builder{}
+	for i, line := range lines {
+		more := ""
+		if i < len(lines)-1 {
+			more = fmt.Sprintf("\n…and %d more", len(lines)-i-1)
+		}
+
+		if text.Len()+len(line)+1+len(more) > limit {
+			if text.Len() == 0 {
+				return truncate(line, limit)
+			}
+
+			text.WriteString(fmt.Sprintf("…and %d more", len(lines)-i))
+			return text.String()
+		}
+
+		text.WriteString(line + "\n")
+	}
+
+	return strings.TrimSuffix(text.String(), "\n")
+}
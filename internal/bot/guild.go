@@ -0,0 +1,358 @@
+package bot
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/charmbracelet/log"
+	"wherd.dev/chad/internal/config"
+)
+
+type GuildSettings struct {
+	Prefix           string   `json:"prefix,omitempty"`
+	DisabledCommands []string `json:"disabled_commands,omitempty"`
+	ModeratorRoles   []string `json:"moderator_roles,omitempty"`
+	WelcomeChannel   string   `json:"welcome_channel,omitempty"`
+	WelcomeMessage   string   `json:"welcome_message,omitempty"`
+
+	Enforcement *config.Enforcement `json:"enforcement,omitempty"` // Rate limit enforcement, the configured one when unset
+
+	AI       *AISettings            `json:"ai,omitempty"`
+	Channels map[string]*AISettings `json:"channels,omitempty"` // AI overrides per channel
+}
+
+// guildSettingsFor returns the settings of the given guild for modification, creating
+// them if needed. The caller must hold the write lock.
+func (b *Bot) guildSettingsFor(guildID string) *GuildSettings {
+	b.dirty.guilds[guildID] = true
+
+	settings, ok := b.guildSettings[guildID]
+	if !ok {
+		settings = &GuildSettings{}
+		b.guildSettings[guildID] = settings
+	}
+
+	return settings
+}
+
+func (b *Bot) guildPrefix(guildID string) string {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	if settings, ok := b.guildSettings[guildID]; ok && settings.Prefix != "" {
+		return settings.Prefix
+	}
+
+	return b.config.Prefix
+}
+
+func (b *Bot) isCommandDisabled(guildID string, command string) bool {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	if settings, ok := b.guildSettings[guildID]; ok {
+		return slices.Contains(settings.DisabledCommands, command)
+	}
+
+	return false
+}
+
+// isAdmin reports whether the user can manage the guild the channel belongs to.
+func isAdmin(s *discordgo.Session, userID string, channelID string) bool {
+	permissions, err := s.UserChannelPermissions(userID, channelID)
+	if err != nil {
+		log.Errorf("Failed to get permissions for user %s: %v", userID, err)
+		return false
+	}
+
+	return permissions&(discordgo.PermissionAdministrator|discordgo.PermissionManageGuild) != 0
+}
+
+// hasPermissions reports whether the user has the given permissions in the channel or has one of the guild's moderator roles.
+func (b *Bot) hasPermissions(s *discordgo.Session, guildID string, channelID string, userID string, member *discordgo.Member, required int64) bool {
+	if guildID == "" {
+		return false
+	}
+
+	permissions, err := s.UserChannelPermissions(userID, channelID)
+	if err != nil {
+		log.Errorf("Failed to get permissions for user %s: %v", userID, err)
+	} else if permissions&discordgo.PermissionAdministrator != 0 || permissions&required == required {
+		return true
+	}
+
+	return b.isModerator(guildID, member)
+}
+
+// isModerator reports whether the member has one of the guild's moderator roles.
+func (b *Bot) isModerator(guildID string, member *discordgo.Member) bool {
+	if member == nil {
+		return false
+	}
+
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	if settings, ok := b.guildSettings[guildID]; ok {
+		for _, role := range member.Roles {
+			if slices.Contains(settings.ModeratorRoles, role) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (b *Bot) welcomeMember(s *discordgo.Session, member *discordgo.Member) {
+	b.mutex.RLock()
+	settings, ok := b.guildSettings[member.GuildID]
+	if !ok || settings.WelcomeChannel == "" {
+		b.mutex.RUnlock()
+		return
+	}
+
+	channelID := settings.WelcomeChannel
+	message := settings.WelcomeMessage
+	b.mutex.RUnlock()
+
+	if message == "" {
+		message = "Welcome to the server, {user}! 👋"
+	}
+
+	message = strings.ReplaceAll(message, "{user}", fmt.Sprintf("<@%s>", member.User.ID))
+	if _, err := s.ChannelMessageSend(channelID, message); err != nil {
+		log.Errorf("Failed to send welcome message to channel %s: %v", channelID, err)
+	}
+}
+
+func (b *Bot) handleSettings(c *CommandContext) {
+	prefix, fields := c.Prefix, c.Args
+	if len(fields) == 0 {
+		c.Reply(b.describeGuildSettings(c.GuildID))
+		return
+	}
+
+	usage := fmt.Sprintf("Usage: `%[1]ssettings [prefix <prefix> | disable <command> | enable <command> | modrole add|remove <role> | welcome <#channel|off> [message] | ratelimit <option> <value> | ratelimit reset]`", prefix)
+
+	var reply string
+	switch strings.ToLower(fields[0]) {
+	case "prefix":
+		if len(fields) != 2 || len(fields[1]) > 5 {
+			c.Reply("Usage: `" + prefix + "settings prefix <prefix>` (max 5 characters)")
+			return
+		}
+
+		b.mutex.Lock()
+		b.guildSettingsFor(c.GuildID).Prefix = fields[1]
+		b.mutex.Unlock()
+		reply = fmt.Sprintf("✅ Prefix set to `%s`", fields[1])
+
+	case "disable", "enable":
+		if len(fields) != 2 {
+			c.Reply(usage)
+			return
+		}
+
+		cmd, ok := b.commands.Lookup(strings.TrimPrefix(fields[1], prefix))
+		if !ok || cmd == c.Command {
+			c.Reply(fmt.Sprintf("❌ Unknown command `%s`", fields[1]))
+			return
+		}
+
+		command := cmd.Name
+		b.mutex.Lock()
+		settings := b.guildSettingsFor(c.GuildID)
+		settings.DisabledCommands = slices.DeleteFunc(settings.DisabledCommands, func(c string) bool { return c == command })
+		if strings.ToLower(fields[0]) == "disable" {
+			settings.DisabledCommands = append(settings.DisabledCommands, command)
+			reply = fmt.Sprintf("✅ Command `%s` disabled", command)
+		} else {
+			reply = fmt.Sprintf("✅ Command `%s` enabled", command)
+		}
+		b.mutex.Unlock()
+
+	case "modrole":
+		if len(fields) != 3 || (strings.ToLower(fields[1]) != "add" && strings.ToLower(fields[1]) != "remove") {
+			c.Reply(usage)
+			return
+		}
+
+		if !isAdmin(c.Session, c.Author.ID, c.ChannelID) {
+			c.Reply("❌ Only members with the Manage Server permission can change moderator roles.")
+			return
+		}
+
+		roleID := strings.TrimSuffix(strings.TrimPrefix(fields[2], "<@&"), ">")
+		b.mutex.Lock()
+		settings := b.guildSettingsFor(c.GuildID)
+		settings.ModeratorRoles = slices.DeleteFunc(settings.ModeratorRoles, func(r string) bool { return r == roleID })
+		if strings.ToLower(fields[1]) == "add" {
+			settings.ModeratorRoles = append(settings.ModeratorRoles, roleID)
+			reply = fmt.Sprintf("✅ <@&%s> is now a moderator role", roleID)
+		} else {
+			reply = fmt.Sprintf("✅ <@&%s> is no longer a moderator role", roleID)
+		}
+		b.mutex.Unlock()
+
+	case "welcome":
+		if len(fields) < 2 {
+			c.Reply(usage)
+			return
+		}
+
+		b.mutex.Lock()
+		settings := b.guildSettingsFor(c.GuildID)
+		if strings.ToLower(fields[1]) == "off" {
+			settings.WelcomeChannel = ""
+			settings.WelcomeMessage = ""
+			reply = "✅ Welcome messages disabled"
+		} else {
+			settings.WelcomeChannel = strings.TrimSuffix(strings.TrimPrefix(fields[1], "<#"), ">")
+			settings.WelcomeMessage = strings.Join(fields[2:], " ")
+			reply = fmt.Sprintf("✅ New members will be welcomed in <#%s>", settings.WelcomeChannel)
+		}
+		b.mutex.Unlock()
+
+	case "ratelimit":
+		var ok bool
+		if reply, ok = b.updateEnforcement(c.GuildID, fields[1:]); !ok {
+			c.Reply("Usage: `" + prefix + "settings ratelimit <cooldown_after | cooldown | timeout_after | timeout | strike_window> <value>` or `" + prefix + "settings ratelimit reset`\nDurations are in seconds or like `5m`, 0 turns a step off. Timeouts last at most 28 days.")
+			return
+		}
+
+	default:
+		c.Reply(usage)
+		return
+	}
+
+	if err := b.saveSettings(); err != nil {
+		log.Errorf("Failed to save settings: %v", err)
+	}
+
+	c.Reply(reply)
+}
+
+func (b *Bot) describeGuildSettings(guildID string) string {
+	prefix := b.guildPrefix(guildID)
+
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	settings, ok := b.guildSettings[guildID]
+	if !ok {
+		settings = &GuildSettings{}
+	}
+
+	disabled := "none"
+	if len(settings.DisabledCommands) > 0 {
+		disabled = "`" + strings.Join(settings.DisabledCommands, "`, `") + "`"
+	}
+
+	roles := "none"
+	if len(settings.ModeratorRoles) > 0 {
+		mentions := make([]string, len(settings.ModeratorRoles))
+		for i, role := range settings.ModeratorRoles {
+			mentions[i] = fmt.Sprintf("<@&%s>", role)
+		}
+		roles = strings.Join(mentions, ", ")
+	}
+
+	welcome := "off"
+	if settings.WelcomeChannel != "" {
+		welcome = fmt.Sprintf("<#%s>", settings.WelcomeChannel)
+	}
+
+	policy := b.config.RateLimit.Enforcement
+	if settings.Enforcement != nil {
+		policy = *settings.Enforcement
+	}
+
+	return fmt.Sprintf("**Server settings**\nPrefix: `%s`\nDisabled commands: %s\nModerator roles: %s\nWelcome channel: %s\nRate limit: %s",
+		prefix, disabled, roles, welcome, describeEnforcement(policy))
+}
+
+// updateEnforcement changes one option of the guild's enforcement policy, or resets it.
+// It returns the reply, or false when the arguments are invalid.
+func (b *Bot) updateEnforcement(guildID string, args []string) (string, bool) {
+	if len(args) == 1 && strings.ToLower(args[0]) == "reset" {
+		b.mutex.Lock()
+		b.guildSettingsFor(guildID).Enforcement = nil
+		b.mutex.Unlock()
+		return "✅ Rate limit enforcement reset to the default", true
+	} else if len(args) != 2 {
+		return "", false
+	}
+
+	option := strings.ToLower(args[0])
+	var count int
+	var seconds int64
+	var err error
+	switch option {
+	case "cooldown_after", "timeout_after":
+		count, err = strconv.Atoi(args[1])
+	case "cooldown", "timeout", "strike_window":
+		seconds, err = parseSeconds(args[1])
+	default:
+		return "", false
+	}
+	if err != nil || count < 0 || seconds < 0 {
+		return "", false
+	}
+	if option == "timeout" && seconds > int64(maxTimeout/time.Second) {
+		return "", false
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	settings := b.guildSettingsFor(guildID)
+	if settings.Enforcement == nil {
+		policy := b.config.RateLimit.Enforcement
+		settings.Enforcement = &policy
+	}
+
+	switch option {
+	case "cooldown_after":
+		settings.Enforcement.CooldownAfter = count
+	case "timeout_after":
+		settings.Enforcement.TimeoutAfter = count
+	case "cooldown":
+		settings.Enforcement.Cooldown = seconds
+	case "timeout":
+		settings.Enforcement.Timeout = seconds
+	case "strike_window":
+		settings.Enforcement.StrikeWindow = seconds
+	}
+
+	return "✅ Rate limit: " + describeEnforcement(*settings.Enforcement), true
+}
+
+func describeEnforcement(policy config.Enforcement) string {
+	cooldown := "never"
+	if policy.CooldownAfter > 0 {
+		cooldown = fmt.Sprintf("%s after %d strikes", time.Duration(policy.Cooldown)*time.Second, policy.CooldownAfter)
+	}
+
+	timeout := "never"
+	if policy.TimeoutAfter > 0 {
+		timeout = fmt.Sprintf("%s after %d strikes", time.Duration(policy.Timeout)*time.Second, policy.TimeoutAfter)
+	}
+
+	return fmt.Sprintf("warn first, cooldown %s, timeout %s, strikes count for %s",
+		cooldown, timeout, time.Duration(policy.StrikeWindow)*time.Second)
+}
+
+// parseSeconds parses a number of seconds or a duration like 5m.
+func parseSeconds(value string) (int64, error) {
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return seconds, nil
+	}
+
+	duration, err := time.ParseDuration(value)
+	return int64(duration / time.Second), err
+}
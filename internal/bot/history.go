@@ -0,0 +1,98 @@
+package bot
+
+import (
+	"time"
+
+	"wherd.dev/chad/internal/openrouter"
+)
+
+type HistoryEntry struct {
+	MessageID string `json:"message_id,omitempty"`
+	AuthorID  string `json:"author_id,omitempty"`
+	Role      string `json:"role"`
+	Content   string `json:"content"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+func (b *Bot) storeMessageForContext(guildID string, channelID string, entry *HistoryEntry) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	history := append(b.messageHistory[channelID], entry)
+
+	// Keep only the last {MaxMessages} messages of the channel
+	if limit := b.config.History.MaxMessages; limit > 0 && len(history) > limit {
+		b.keepForSummary(channelID, history[:len(history)-limit])
+		history = history[len(history)-limit:]
+	}
+
+	b.messageHistory[channelID] = history
+	b.dirty.channels[channelID] = true
+
+	b.maybeSummarize(guildID, channelID)
+}
+
+// addContext fits the channel's history into the request. It remembers how many of the
+// latest messages made it, the older ones are left to the summary.
+func (b *Bot) addContext(o *openrouter.OpenRouter, req *openrouter.Request, guildID string, channelID string) {
+	kept := o.FitContext(req, b.channelContext(channelID, o.MaxMessagesInContext))
+
+	b.mutex.Lock()
+	b.contextKept[channelID] = kept
+	b.maybeSummarize(guildID, channelID)
+	b.mutex.Unlock()
+}
+
+// channelContext returns the messages of the channel that are still within the retention
+// period, up to limit of the most recent ones when it isn't 0, ready to be fitted into
+// a request.
+func (b *Bot) channelContext(channelID string, limit int) []*openrouter.Message {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	history := b.messageHistory[channelID]
+	if limit > 0 && len(history) > limit {
+		history = history[len(history)-limit:]
+	}
+
+	cutoff := b.historyCutoff()
+	messages := make([]*openrouter.Message, 0, len(history))
+	for _, entry := range history {
+		if entry.Timestamp >= cutoff {
+			messages = append(messages, &openrouter.Message{Role: entry.Role, Content: entry.Content})
+		}
+	}
+
+	return messages
+}
+
+// pruneHistory drops messages older than the retention period.
+// The caller must hold the write lock.
+func (b *Bot) pruneHistory() {
+	cutoff := b.historyCutoff()
+	for channelID, history := range b.messageHistory {
+		i := 0
+		for i < len(history) && history[i].Timestamp < cutoff {
+			i++
+		}
+
+		if i == len(history) {
+			delete(b.messageHistory, channelID)
+			delete(b.contextKept, channelID)
+		} else if i > 0 {
+			b.messageHistory[channelID] = history[i:]
+		}
+
+		if i > 0 {
+			b.dirty.channels[channelID] = true
+		}
+	}
+}
+
+func (b *Bot) historyCutoff() int64 {
+	if b.config.History.Retention <= 0 {
+		return 0
+	}
+
+	return time.Now().Add(-time.Duration(b.config.History.Retention) * time.Hour).Unix()
+}
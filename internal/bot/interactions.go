@@ -0,0 +1,202 @@
+package bot
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/charmbracelet/log"
+)
+
+func (b *Bot) interactionCreate(s *discordgo.Session, event *discordgo.InteractionCreate) {
+	switch event.Type {
+	case discordgo.InteractionApplicationCommand:
+		b.handleSlashCommand(s, event)
+	case discordgo.InteractionMessageComponent:
+		b.handleComponent(s, event)
+	}
+}
+
+func (b *Bot) handleSlashCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	data := i.ApplicationCommandData()
+
+	user := i.User
+	if i.Member != nil {
+		user = i.Member.User
+	}
+
+	cmd, ok := b.commands.Lookup(data.Name)
+	if !ok || !cmd.Slash {
+		respondEphemeral(s, i, "❌ Unknown command.")
+		return
+	}
+
+	if b.isCommandDisabled(i.GuildID, cmd.Name) {
+		respondEphemeral(s, i, "❌ This command is disabled in this server.")
+		return
+	}
+
+	if cmd.Permissions != 0 && !b.hasPermissions(s, i.GuildID, i.ChannelID, user.ID, i.Member, cmd.Permissions) {
+		respondEphemeral(s, i, "❌ You don't have permission to use this command.")
+		return
+	}
+
+	if p, notice := b.enforceRateLimit(s, i.GuildID, i.ChannelID, i.Member, user, cmd.Name); p != penaltyNone {
+		respondEphemeral(s, i, notice)
+		return
+	}
+
+	args := slashArgs(cmd.Options, data.Options)
+	raw := strings.Join(args, " ")
+
+	// Slash commands never reach messageCreate, keep the channel context complete
+	b.storeMessageForContext(i.GuildID, i.ChannelID, &HistoryEntry{
+		MessageID: i.ID,
+		AuthorID:  user.ID,
+		Role:      "user",
+		Content:   fmt.Sprintf("%s: /%s %s", user.Username, cmd.Name, raw),
+		Timestamp: time.Now().Unix(),
+	})
+
+	cmd.Handler(&CommandContext{
+		Session:     s,
+		Interaction: i,
+		Command:     cmd,
+		Prefix:      "/",
+		Args:        args,
+		Raw:         raw,
+		GuildID:     i.GuildID,
+		ChannelID:   i.ChannelID,
+		Author:      user,
+		Member:      i.Member,
+	})
+}
+
+// slashArgs returns the option values in the order the command declares them.
+// Subcommands come first by name, followed by their own options.
+func slashArgs(declared []*discordgo.ApplicationCommandOption, options []*discordgo.ApplicationCommandInteractionDataOption) []string {
+	args := []string{}
+	for _, d := range declared {
+		for _, option := range options {
+			if option.Name != d.Name {
+				continue
+			}
+
+			switch option.Type {
+			case discordgo.ApplicationCommandOptionSubCommand:
+				args = append(args, option.Name)
+				args = append(args, slashArgs(d.Options, option.Options)...)
+			case discordgo.ApplicationCommandOptionString:
+				args = append(args, option.StringValue())
+			case discordgo.ApplicationCommandOptionInteger:
+				args = append(args, strconv.FormatInt(option.IntValue(), 10))
+			case discordgo.ApplicationCommandOptionBoolean:
+				args = append(args, strconv.FormatBool(option.BoolValue()))
+			default:
+				args = append(args, fmt.Sprint(option.Value))
+			}
+		}
+	}
+
+	return args
+}
+
+// handleComponent dispatches button clicks by the first part of their custom ID, "<action>:<args>".
+func (b *Bot) handleComponent(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	action, args, _ := strings.Cut(i.MessageComponentData().CustomID, ":")
+	switch action {
+	case "reminder_snooze":
+		b.snoozeReminder(s, i, strings.Split(args, ":"))
+	default:
+		respondEphemeral(s, i, "❌ This button doesn't work anymore.")
+	}
+}
+
+func respondEphemeral(s *discordgo.Session, i *discordgo.InteractionCreate, content string) {
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+
+	if err != nil {
+		log.Errorf("Failed to respond to interaction: %v", err)
+	}
+}
+
+func (b *Bot) applicationCommands() []*discordgo.ApplicationCommand {
+	commands := []*discordgo.ApplicationCommand{}
+	for _, cmd := range b.commands.Commands() {
+		if !cmd.Slash {
+			continue
+		}
+
+		command := &discordgo.ApplicationCommand{
+			Name:        cmd.Name,
+			Description: cmd.Description,
+			Options:     cmd.Options,
+		}
+
+		if cmd.Permissions != 0 {
+			permissions := cmd.Permissions
+			command.DefaultMemberPermissions = &permissions
+		}
+
+		commands = append(commands, command)
+	}
+
+	return commands
+}
+
+// registerSlashCommands registers the application commands either globally or in
+// the configured guilds, and removes the commands registered in the other scope.
+func (b *Bot) registerSlashCommands(s *discordgo.Session, guilds []*discordgo.Guild) {
+	appID := s.State.User.ID
+
+	commands := []*discordgo.ApplicationCommand{}
+	if b.config.SlashCommands.Enabled {
+		commands = b.applicationCommands()
+	}
+
+	scoped := b.config.SlashCommands.Guilds
+	if len(scoped) == 0 {
+		if _, err := s.ApplicationCommandBulkOverwrite(appID, "", commands); err != nil {
+			log.Errorf("Failed to register global slash commands: %v", err)
+		}
+	} else {
+		removeApplicationCommands(s, appID, "")
+	}
+
+	for _, guildID := range scoped {
+		if _, err := s.ApplicationCommandBulkOverwrite(appID, guildID, commands); err != nil {
+			log.Errorf("Failed to register slash commands in guild %s: %v", guildID, err)
+		}
+	}
+
+	for _, guild := range guilds {
+		if !slices.Contains(scoped, guild.ID) {
+			removeApplicationCommands(s, appID, guild.ID)
+		}
+	}
+
+	log.Infof("Registered %d slash commands", len(commands))
+}
+
+func removeApplicationCommands(s *discordgo.Session, appID string, guildID string) {
+	stale, err := s.ApplicationCommands(appID, guildID)
+	if err != nil {
+		log.Errorf("Failed to list slash commands: %v", err)
+		return
+	}
+
+	for _, command := range stale {
+		if err := s.ApplicationCommandDelete(appID, guildID, command.ID); err != nil {
+			log.Errorf("Failed to remove stale slash command %s: %v", command.Name, err)
+		}
+	}
+}
@@ -0,0 +1,289 @@
+package bot
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/log"
+	"wherd.dev/chad/internal/openrouter"
+)
+
+// Tasks the AI does, each can use its own model.
+const (
+	taskChat      = "chat" // Joining conversations unprompted
+	taskMention   = "mention"
+	taskAsk       = "ask"
+	taskFactcheck = "factcheck"
+	taskSummary   = "summary" // Condensing the messages that fell out of the context
+)
+
+var aiTasks = []string{taskChat, taskMention, taskAsk, taskFactcheck, taskSummary}
+
+// AISettings overrides the configured OpenRouter settings. Empty fields keep the
+// value inherited from the guild or the config file.
+type AISettings struct {
+	Persona     string            `json:"persona,omitempty"`
+	Model       string            `json:"model,omitempty"`
+	Fallbacks   []string          `json:"fallbacks,omitempty"`
+	Tasks       map[string]string `json:"tasks,omitempty"` // Task to model, over Model
+	Temperature *float64          `json:"temperature,omitempty"`
+	MaxTokens   int               `json:"max_tokens,omitempty"`
+}
+
+func (a *AISettings) apply(o *openrouter.OpenRouter, task string) {
+	if a == nil {
+		return
+	}
+
+	if a.Persona != "" {
+		o.SystemPrompt = a.Persona
+	}
+	if a.Model != "" {
+		o.Model = a.Model
+	}
+	if model := a.Tasks[task]; model != "" {
+		o.Model = model
+	}
+	if len(a.Fallbacks) > 0 {
+		o.Fallbacks = a.Fallbacks
+	}
+	if a.Temperature != nil {
+		o.Temperature = a.Temperature
+	}
+	if a.MaxTokens > 0 {
+		o.MaxTokens = a.MaxTokens
+	}
+}
+
+func (a *AISettings) isEmpty() bool {
+	return a.Persona == "" && a.Model == "" && len(a.Fallbacks) == 0 && len(a.Tasks) == 0 && a.Temperature == nil && a.MaxTokens == 0
+}
+
+// newClient returns an OpenRouter client configured for the task in the channel, which charges
+// its usage to the guild and user. Channel overrides take precedence over guild overrides, which
+// take precedence over the config file. At every level a model set for the task takes precedence
+// over the general one.
+func (b *Bot) newClient(guildID string, channelID string, userID string, task string) *openrouter.OpenRouter {
+	o := openrouter.New(
+		b.config.OpenRouter.Key,
+		b.config.OpenRouter.SystemPrompt,
+		b.config.OpenRouter.Temperature,
+		b.config.OpenRouter.MaxTokens,
+		b.config.OpenRouter.MaxMessagesInContext,
+		b.config.OpenRouter.Model,
+	)
+	if model := b.config.OpenRouter.Tasks[task]; model != "" {
+		o.Model = model
+	}
+	o.Fallbacks = b.config.OpenRouter.Fallbacks
+	o.MaxRetries = b.config.OpenRouter.MaxRetries
+	o.BaseURL = b.config.OpenRouter.BaseURL
+	o.ContextTokens = b.config.OpenRouter.ContextTokens
+	o.MaxMessageTokens = b.config.OpenRouter.MaxMessageTokens
+	if b.config.Cache.Completions.Size > 0 {
+		o.Cache = b.completionCache
+	}
+	o.OnResponse = func(response *openrouter.Response) {
+		b.recordUsage(guildID, userID, response)
+	}
+
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	if settings, ok := b.guildSettings[guildID]; ok {
+		settings.AI.apply(o, task)
+		settings.Channels[channelID].apply(o, task)
+	}
+
+	return o
+}
+
+func (b *Bot) handleAI(c *CommandContext) {
+	args := c.Args
+	if len(args) == 0 || strings.ToLower(args[0]) == "show" {
+		c.Reply(b.describeAISettings(c.GuildID, c.ChannelID))
+		return
+	}
+
+	scope, skip := "server", 1
+	if strings.ToLower(args[0]) == "channel" {
+		scope, skip = "channel", 2
+		args = args[1:]
+	}
+
+	if len(args) == 0 {
+		c.ReplyUsage("channel persona You are a pirate")
+		return
+	}
+
+	// Keep the value as typed, personas often contain quotes
+	field := strings.ToLower(args[0])
+	value := c.RawAfter(skip)
+
+	b.mutex.Lock()
+	settings := b.guildSettingsFor(c.GuildID)
+	target := settings.AI
+	if scope == "channel" {
+		target = settings.Channels[c.ChannelID]
+	}
+	if target == nil {
+		target = &AISettings{}
+	}
+
+	reply, err := updateAISettings(target, field, value)
+	if err == nil {
+		if scope == "channel" {
+			if settings.Channels == nil {
+				settings.Channels = map[string]*AISettings{}
+			}
+			settings.Channels[c.ChannelID] = target
+			if target.isEmpty() {
+				delete(settings.Channels, c.ChannelID)
+			}
+		} else {
+			settings.AI = target
+			if target.isEmpty() {
+				settings.AI = nil
+			}
+		}
+	}
+	b.mutex.Unlock()
+
+	if err != nil {
+		c.Reply("❌ " + err.Error())
+		return
+	}
+
+	if err := b.saveSettings(); err != nil {
+		log.Errorf("Failed to save settings: %v", err)
+	}
+
+	c.Reply(fmt.Sprintf("✅ %s for this %s", reply, scope))
+}
+
+func updateAISettings(a *AISettings, field string, value string) (string, error) {
+	switch field {
+	case "persona", "prompt":
+		if value == "" {
+			return "", fmt.Errorf("tell me who I should be, eg. `persona You are a grumpy pirate`")
+		}
+		a.Persona = value
+		return "Persona updated", nil
+
+	case "model":
+		if value == "" || strings.ContainsAny(value, " \t") {
+			return "", fmt.Errorf("model must be an OpenRouter model ID, eg. `openai/gpt-4o-mini`")
+		}
+		a.Model = value
+		return fmt.Sprintf("Model set to `%s`", value), nil
+
+	case "fallbacks", "fallback":
+		models := strings.Fields(value)
+		if len(models) == 0 {
+			return "", fmt.Errorf("list the models to try in order when the model fails, eg. `fallbacks openai/gpt-4o-mini meta-llama/llama-3.1-70b-instruct`")
+		}
+		a.Fallbacks = models
+		return fmt.Sprintf("Fallback models set to `%s`", strings.Join(models, "`, `")), nil
+
+	case "task":
+		task, model, _ := strings.Cut(value, " ")
+		task, model = strings.ToLower(task), strings.TrimSpace(model)
+		if !slices.Contains(aiTasks, task) || model == "" || strings.ContainsAny(model, " \t") {
+			return "", fmt.Errorf("use `task <%s> <model>`, eg. `task chat openai/gpt-4o-mini`", strings.Join(aiTasks, " | "))
+		}
+		if a.Tasks == nil {
+			a.Tasks = map[string]string{}
+		}
+		a.Tasks[task] = model
+		return fmt.Sprintf("Model for %s set to `%s`", task, model), nil
+
+	case "temperature", "temp":
+		temperature, err := strconv.ParseFloat(value, 64)
+		if err != nil || temperature < 0 || temperature > 2 {
+			return "", fmt.Errorf("temperature must be a number between 0 and 2")
+		}
+		a.Temperature = &temperature
+		return fmt.Sprintf("Temperature set to %.2f", temperature), nil
+
+	case "maxtokens", "max_tokens":
+		maxTokens, err := strconv.Atoi(value)
+		if err != nil || maxTokens < 1 {
+			return "", fmt.Errorf("max tokens must be a positive number")
+		}
+		a.MaxTokens = maxTokens
+		return fmt.Sprintf("Max tokens set to %d", maxTokens), nil
+
+	case "reset":
+		setting, task, _ := strings.Cut(strings.ToLower(value), " ")
+		switch setting {
+		case "":
+			*a = AISettings{}
+		case "persona", "prompt":
+			a.Persona = ""
+		case "model":
+			a.Model = ""
+		case "fallbacks", "fallback":
+			a.Fallbacks = nil
+		case "task", "tasks":
+			if task == "" {
+				a.Tasks = nil
+			} else {
+				delete(a.Tasks, task)
+			}
+		case "temperature", "temp":
+			a.Temperature = nil
+		case "maxtokens", "max_tokens":
+			a.MaxTokens = 0
+		default:
+			return "", fmt.Errorf("unknown setting `%s`", value)
+		}
+		return "Overrides reset", nil
+	}
+
+	return "", fmt.Errorf("unknown setting `%s`, use persona, model, fallbacks, task, temperature, maxtokens or reset", field)
+}
+
+func (b *Bot) describeAISettings(guildID string, channelID string) string {
+	o := b.newClient(guildID, channelID, "", "")
+
+	// Only the tasks that don't use the general model
+	tasks := []string{}
+	for _, task := range aiTasks {
+		if model := b.newClient(guildID, channelID, "", task).Model; model != o.Model {
+			tasks = append(tasks, fmt.Sprintf("%s `%s`", task, model))
+		}
+	}
+
+	fallbacks := "none"
+	if len(o.Fallbacks) > 0 {
+		fallbacks = "`" + strings.Join(o.Fallbacks, "`, `") + "`"
+	}
+
+	temperature := "model default"
+	if o.Temperature != nil {
+		temperature = fmt.Sprintf("%.2f", *o.Temperature)
+	}
+
+	maxTokens := "model default"
+	if o.MaxTokens > 0 {
+		maxTokens = strconv.Itoa(o.MaxTokens)
+	}
+
+	persona := o.SystemPrompt
+	if persona == "" {
+		persona = "none"
+	} else if runes := []rune(persona); len(runes) > 1000 {
+		persona = string(runes[:1000]) + "…"
+	}
+
+	models := fmt.Sprintf("Model: `%s`\n", o.Model)
+	if len(tasks) > 0 {
+		models += fmt.Sprintf("Models for tasks: %s\n", strings.Join(tasks, ", "))
+	}
+	models += fmt.Sprintf("Fallback models: %s\n", fallbacks)
+
+	return fmt.Sprintf("**AI settings for this channel**\n%sTemperature: %s\nMax tokens: %s\nPersona: %s",
+		models, temperature, maxTokens, persona)
+}
@@ -0,0 +1,85 @@
+package bot
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"wherd.dev/chad/internal/config"
+	"wherd.dev/chad/internal/ratelimit"
+)
+
+func newLimiter(cfg config.RateLimit) *ratelimit.Limiter {
+	return ratelimit.New(map[ratelimit.Scope]ratelimit.Limit{
+		ratelimit.User:    {Max: cfg.MaxRequests, Window: time.Duration(cfg.Window) * time.Second},
+		ratelimit.Guild:   {Max: cfg.Guild.MaxRequests, Window: time.Duration(cfg.Guild.Window) * time.Second},
+		ratelimit.Channel: {Max: cfg.Channel.MaxRequests, Window: time.Duration(cfg.Channel.Window) * time.Second},
+	})
+}
+
+// actionCost returns what a command or "mention" costs.
+func (b *Bot) actionCost(action string) int {
+	if cost, ok := b.config.RateLimit.Costs[action]; ok {
+		return cost
+	}
+
+	return 1
+}
+
+// checkRateLimit charges an action to the user, the guild and the channel.
+func (b *Bot) checkRateLimit(guildID string, channelID string, member *discordgo.Member, userID string, action string) ratelimit.Result {
+	if b.config.RateLimit.ExemptModerators && b.isModerator(guildID, member) {
+		return ratelimit.Result{Allowed: true}
+	}
+
+	request := ratelimit.Request{
+		UserID:    userID,
+		GuildID:   guildID,
+		ChannelID: channelID,
+		Cost:      b.actionCost(action),
+	}
+
+	result := b.limiter.Allow(request)
+	if result.Allowed && request.Cost > 0 {
+		b.mutex.Lock()
+		b.dirty.rateLimits = true
+		b.mutex.Unlock()
+	}
+
+	return result
+}
+
+// messageAction returns the command name a message invokes, "mention" when it mentions the bot or "message".
+func (b *Bot) messageAction(s *discordgo.Session, m *discordgo.MessageCreate) string {
+	prefix := b.guildPrefix(m.GuildID)
+	if strings.HasPrefix(m.Content, prefix) {
+		name, _, _ := strings.Cut(strings.TrimSpace(strings.TrimPrefix(m.Content, prefix)), " ")
+		if cmd, ok := b.commands.Lookup(name); ok {
+			return cmd.Name
+		}
+	}
+
+	for _, mention := range m.Mentions {
+		if mention.ID == s.State.User.ID {
+			return "mention"
+		}
+	}
+
+	return "message"
+}
+
+// rateLimitMessage explains a denied request.
+func rateLimitMessage(result ratelimit.Result) string {
+	retry := fmt.Sprintf("%ds", int(math.Ceil(result.RetryAfter.Seconds())))
+
+	switch result.Scope {
+	case ratelimit.Guild:
+		return "⏰ This server is keeping me busy, try again in " + retry + "."
+	case ratelimit.Channel:
+		return "⏰ This channel is keeping me busy, try again in " + retry + "."
+	}
+
+	return "⏰ You're sending commands too fast, try again in " + retry + "."
+}
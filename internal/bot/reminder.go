@@ -1,74 +1,334 @@
 package bot
 
 import (
+	"cmp"
 	"fmt"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/bwmarrin/discordgo"
 	"github.com/charmbracelet/log"
+	"wherd.dev/chad/internal/when"
 )
 
+// minReminderInterval keeps recurring reminders from turning into spam.
+const minReminderInterval = 10 * time.Minute
+
+// reminderIntro precedes the text of a delivered reminder, snoozing reads the text back from after it.
+const reminderIntro = "You asked me to remind you about this: "
+
+// snoozeOptions are the snooze buttons under a delivered reminder, in minutes.
+var snoozeOptions = []struct {
+	label   string
+	minutes int
+}{
+	{"10 minutes", 10},
+	{"1 hour", 60},
+	{"Tomorrow", 24 * 60},
+}
+
 type Reminder struct {
-	ID        string `json:"id"`
-	ChannelID string `json:"channel_id"`
-	UserID    string `json:"user_id"`
-	Message   string `json:"message"`
-	Time      int64  `json:"time"`
-}
-
-func (b *Bot) scheduleReminder(reminder *Reminder) {
-	duration := time.Until(time.Unix(reminder.Time, 0))
-	if duration <= 0 {
-		// Reminder is already due, send immediately
-		b.sendReminder(reminder)
-		return
+	ID         string `json:"id"`
+	ChannelID  string `json:"channel_id"`
+	UserID     string `json:"user_id"`
+	Message    string `json:"message"`
+	Time       int64  `json:"time"`
+	Recurrence string `json:"recurrence,omitempty"` // RRULE subset, see when.Rule
+	TimeZone   string `json:"time_zone,omitempty"`  // Where recurrences keep their time of day
+	Retries    int    `json:"retries,omitempty"`    // Failed deliveries of the current occurrence
+	RetryAt    int64  `json:"retry_at,omitempty"`   // When delivery is tried again, after a failure
+}
+
+// due returns when the reminder is next delivered, later than its time after a failed delivery.
+func (r *Reminder) due() int64 {
+	return max(r.Time, r.RetryAt)
+}
+
+// rule returns the recurrence rule of the reminder, nil when it goes off only once.
+func (r *Reminder) rule() *when.Rule {
+	if r.Recurrence == "" {
+		return nil
 	}
 
-	timer := time.AfterFunc(duration, func() {
-		b.sendReminder(reminder)
-		b.removeReminder(reminder.ID)
-	})
+	rule, err := when.ParseRule(r.Recurrence)
+	if err != nil {
+		log.Errorf("Invalid recurrence of reminder %s: %v", r.ID, err)
+		return nil
+	}
 
-	b.reminderTimers[reminder.ID] = timer
+	return rule
+}
+
+// nextTime returns the first occurrence of a recurring reminder after the given time.
+func (r *Reminder) nextTime(rule *when.Rule, after time.Time) int64 {
+	loc, err := time.LoadLocation(r.TimeZone)
+	if err != nil {
+		loc = time.UTC
+	}
+
+	return rule.After(time.Unix(r.Time, 0).In(loc), after).Unix()
+}
+
+// addReminder numbers a new reminder and schedules it.
+func (b *Bot) addReminder(reminder *Reminder) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	// Generate unique ID with incremental counter
+	b.reminderCounter++
+	reminder.ID = fmt.Sprintf("%s_%d", reminder.UserID, b.reminderCounter)
+
+	b.reminders = append(b.reminders, reminder)
+	b.dirty.reminders = true
+	b.scheduleReminder(reminder)
+}
+
+// rescheduleReminder queues a reminder whose time changed, its old place in the queue is skipped.
+// The caller must hold the write lock.
+func (b *Bot) rescheduleReminder(reminder *Reminder) {
+	reminder.Retries, reminder.RetryAt = 0, 0
+	b.dirty.reminders = true
+	b.scheduleReminder(reminder)
 }
 
 func (b *Bot) removeReminder(reminderID string) {
 	b.mutex.Lock()
 	defer b.mutex.Unlock()
 
-	delete(b.reminderTimers, reminderID)
 	for i, r := range b.reminders {
 		if r.ID == reminderID {
 			b.reminders = append(b.reminders[:i], b.reminders[i+1:]...)
+			b.dirty.reminders = true
 			break
 		}
 	}
 }
 
-func (b *Bot) sendReminder(reminder *Reminder) {
-	content := fmt.Sprintf("<@%s> You asked me to remind you about this: %s", reminder.UserID, reminder.Message)
-	if _, err := b.session.ChannelMessageSend(reminder.ChannelID, content); err != nil {
-		log.Errorf("Failed to send reminder to channel %s: %v", reminder.ChannelID, err)
+// reminderMessage builds a delivered reminder with its snooze buttons. Notes like
+// lateness go before the intro, so snoozing can still read the text back.
+func reminderMessage(reminder *Reminder, notes ...string) *discordgo.MessageSend {
+	buttons := []discordgo.MessageComponent{}
+	for _, option := range snoozeOptions {
+		buttons = append(buttons, discordgo.Button{
+			Label:    option.label,
+			Style:    discordgo.SecondaryButton,
+			Emoji:    &discordgo.ComponentEmoji{Name: "💤"},
+			CustomID: fmt.Sprintf("reminder_snooze:%s:%d", reminder.UserID, option.minutes),
+		})
+	}
+
+	content := fmt.Sprintf("<@%s> ", reminder.UserID)
+	for _, note := range notes {
+		content += fmt.Sprintf("*(%s)* ", note)
+	}
+
+	return &discordgo.MessageSend{
+		Content:    content + reminderIntro + reminder.Message,
+		Components: []discordgo.MessageComponent{discordgo.ActionsRow{Components: buttons}},
+	}
+}
+
+// snoozeReminder handles the snooze buttons, args are the owner's user ID and the minutes to snooze for.
+func (b *Bot) snoozeReminder(s *discordgo.Session, i *discordgo.InteractionCreate, args []string) {
+	user := i.User
+	if i.Member != nil {
+		user = i.Member.User
+	}
+
+	if len(args) != 2 {
+		respondEphemeral(s, i, "❌ This button doesn't work anymore.")
+		return
+	}
+
+	if user.ID != args[0] {
+		respondEphemeral(s, i, fmt.Sprintf("❌ Only <@%s> can snooze this reminder.", args[0]))
+		return
+	}
+
+	minutes, err := strconv.Atoi(args[1])
+	_, message, ok := strings.Cut(i.Message.Content, reminderIntro)
+	if err != nil || !ok {
+		respondEphemeral(s, i, "❌ This button doesn't work anymore.")
+		return
+	}
+
+	reminder := &Reminder{
+		ChannelID: i.ChannelID,
+		UserID:    user.ID,
+		Message:   message,
+		Time:      time.Now().Add(time.Duration(minutes) * time.Minute).Unix(),
+		TimeZone:  b.userLocation(user.ID).String(),
+	}
+	b.addReminder(reminder)
+
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content:    fmt.Sprintf("%s\n-# 💤 Snoozed until <t:%d:f> as `#%s`", i.Message.Content, reminder.Time, reminder.number()),
+			Components: []discordgo.MessageComponent{},
+		},
+	})
+	if err != nil {
+		log.Errorf("Failed to respond to snooze: %v", err)
 	}
 }
 
-func (b *Bot) initializeReminders() {
-	b.mutex.Lock()
+// number returns the short form of the reminder ID users refer to it by.
+func (r *Reminder) number() string {
+	_, number, _ := strings.Cut(r.ID, "_")
+	return number
+}
+
+// findReminder looks a reminder up by its number, with or without "#", or its full ID.
+func (b *Bot) findReminder(ref string) *Reminder {
+	ref = strings.TrimPrefix(ref, "#")
+
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	for _, reminder := range b.reminders {
+		if reminder.ID == ref || reminder.number() == ref {
+			return reminder
+		}
+	}
+
+	return nil
+}
+
+// canManageReminder reports whether the user owns the reminder, or may manage messages in the channel it goes off in.
+func (b *Bot) canManageReminder(c *CommandContext, reminder *Reminder) bool {
+	if reminder.UserID == c.Author.ID {
+		return true
+	}
+
+	return reminder.ChannelID == c.ChannelID &&
+		b.hasPermissions(c.Session, c.GuildID, c.ChannelID, c.Author.ID, c.Member, discordgo.PermissionManageMessages)
+}
+
+// handleReminderList lists the author's reminders, or with "channel" every reminder in the channel for moderators.
+func (b *Bot) handleReminderList(c *CommandContext) {
+	channel := len(c.Args) > 1 && strings.ToLower(c.Args[1]) == "channel"
+	if channel && !b.hasPermissions(c.Session, c.GuildID, c.ChannelID, c.Author.ID, c.Member, discordgo.PermissionManageMessages) {
+		c.Reply("❌ Only moderators can list everyone's reminders.")
+		return
+	}
+
+	b.mutex.RLock()
+	reminders := []*Reminder{}
 	for _, reminder := range b.reminders {
-		if reminder.Time > time.Now().Unix() {
-			b.scheduleReminder(reminder)
+		if (channel && reminder.ChannelID == c.ChannelID) || (!channel && reminder.UserID == c.Author.ID) {
+			r := *reminder
+			reminders = append(reminders, &r)
 		}
 	}
-	b.mutex.Unlock()
+	b.mutex.RUnlock()
+
+	if len(reminders) == 0 {
+		c.Reply("📭 No reminders.")
+		return
+	}
+
+	slices.SortFunc(reminders, func(x, y *Reminder) int { return cmp.Compare(x.Time, y.Time) })
+
+	list := &strings.Builder{}
+	if channel {
+		fmt.Fprintf(list, "**Reminders in <#%s>**\n", c.ChannelID)
+	} else {
+		list.WriteString("**Your reminders**\n")
+	}
+
+	for _, reminder := range reminders {
+		fmt.Fprintf(list, "`#%s` <t:%d:R>", reminder.number(), reminder.Time)
+		if rule := reminder.rule(); rule != nil {
+			fmt.Fprintf(list, " (%s)", rule.Describe())
+		}
+		if channel {
+			fmt.Fprintf(list, " for <@%s>", reminder.UserID)
+		} else if reminder.ChannelID != c.ChannelID {
+			fmt.Fprintf(list, " in <#%s>", reminder.ChannelID)
+		}
+		fmt.Fprintf(list, ": %s\n", truncate(reminder.Message, 100))
+	}
+
+	if err := b.deliverReply(c, list.String()); err != nil {
+		log.Errorf("Failed to send reminder list: %v", err)
+	}
 }
 
-func (b *Bot) shutdownReminders() {
-	b.mutex.Lock()
-	defer b.mutex.Unlock()
+// handleReminderCancel removes a reminder.
+func (b *Bot) handleReminderCancel(c *CommandContext) {
+	if len(c.Args) != 2 {
+		c.Reply(fmt.Sprintf("Usage: `%sremind cancel <number>`, see `%sremind list`", c.Prefix, c.Prefix))
+		return
+	}
 
-	// Cancel all active timers
-	for _, timer := range b.reminderTimers {
-		timer.Stop()
+	reminder := b.findReminder(c.Args[1])
+	if reminder == nil || !b.canManageReminder(c, reminder) {
+		c.Reply(fmt.Sprintf("❌ You have no reminder `#%s`.", strings.TrimPrefix(c.Args[1], "#")))
+		return
 	}
-	b.reminderTimers = make(map[string]*time.Timer)
-	log.Info("All reminder timers stopped")
+
+	b.removeReminder(reminder.ID)
+	if err := b.saveSettings(); err != nil {
+		log.Errorf("Failed to save settings: %v", err)
+	}
+
+	c.Reply(fmt.Sprintf("🗑️ Cancelled reminder `#%s`: %s", reminder.number(), truncate(reminder.Message, 100)))
+}
+
+// handleReminderEdit changes the time and optionally the text of a reminder, or only the text with "text".
+func (b *Bot) handleReminderEdit(c *CommandContext) {
+	if len(c.Args) < 3 {
+		c.Reply(fmt.Sprintf("Usage: `%[1]sremind edit <number> <time> [message]` or `%[1]sremind edit <number> text <message>`", c.Prefix))
+		return
+	}
+
+	reminder := b.findReminder(c.Args[1])
+	if reminder == nil || !b.canManageReminder(c, reminder) {
+		c.Reply(fmt.Sprintf("❌ You have no reminder `#%s`.", strings.TrimPrefix(c.Args[1], "#")))
+		return
+	}
+
+	if strings.ToLower(c.Args[2]) == "text" {
+		message := strings.Join(c.Args[3:], " ")
+		if message == "" {
+			c.Reply("❌ What should I remind about instead?")
+			return
+		}
+
+		b.mutex.Lock()
+		reminder.Message = message
+		b.dirty.reminders = true
+		b.mutex.Unlock()
+	} else {
+		loc := b.userLocation(reminder.UserID)
+		result, message, ok := b.parseReminderTime(c, c.Args[2:], loc)
+		if !ok {
+			return
+		}
+
+		b.mutex.Lock()
+		reminder.Time = result.Time.Unix()
+		reminder.TimeZone = loc.String()
+		reminder.Recurrence = ""
+		if result.Rule != nil {
+			reminder.Recurrence = result.Rule.String()
+		}
+		if message != "" {
+			reminder.Message = message
+		}
+		b.rescheduleReminder(reminder)
+		b.mutex.Unlock()
+	}
+
+	if err := b.saveSettings(); err != nil {
+		log.Errorf("Failed to save settings: %v", err)
+	}
+
+	b.mutex.RLock()
+	reply := fmt.Sprintf("✏️ Reminder `#%s` now goes off <t:%d:R>: %s", reminder.number(), reminder.Time, reminder.Message)
+	b.mutex.RUnlock()
+	c.Reply(reply)
 }
@@ -0,0 +1,95 @@
+package bot
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// replier delivers a response, either by replacing a placeholder or as a new message.
+type replier interface {
+	edit(data *discordgo.MessageSend) error
+	send(data *discordgo.MessageSend) error
+}
+
+// messageReplier replies in a channel, replacing the placeholder message if there is one.
+type messageReplier struct {
+	session     *discordgo.Session
+	channelID   string
+	placeholder *discordgo.Message
+}
+
+func (r *messageReplier) edit(data *discordgo.MessageSend) error {
+	if r.placeholder == nil {
+		return r.send(data)
+	}
+
+	embeds := data.Embeds
+	_, err := r.session.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		ID:      r.placeholder.ID,
+		Channel: r.placeholder.ChannelID,
+		Content: &data.Content,
+		Embeds:  &embeds,
+		Files:   data.Files,
+	})
+	return err
+}
+
+func (r *messageReplier) send(data *discordgo.MessageSend) error {
+	_, err := r.session.ChannelMessageSendComplex(r.channelID, data)
+	return err
+}
+
+// deliverReply sends content through the replier, split over as many messages as
+// needed. Replies longer than the configured threshold are attached as a file instead.
+func (b *Bot) deliverReply(r replier, content string) error {
+	if threshold := b.config.AttachRepliesOver; threshold > 0 && utf8.RuneCountInString(content) > threshold {
+		return r.edit(&discordgo.MessageSend{
+			Content: "📄 That's a long one, so I attached the full reply.",
+			Files: []*discordgo.File{
+				{Name: "reply.md", ContentType: "text/markdown", Reader: strings.NewReader(content)},
+			},
+		})
+	}
+
+	chunks := splitMessage(content, messageLimit)
+	if err := r.edit(&discordgo.MessageSend{Content: chunks[0]}); err != nil {
+		return err
+	}
+
+	for _, chunk := range chunks[1:] {
+		if err := r.send(&discordgo.MessageSend{Content: chunk}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deliverEmbed sends the embed through the replier. A description over Discord's limit
+// continues in follow-up embeds, and the last one carries the fields and footer.
+func deliverEmbed(r replier, embed *discordgo.MessageEmbed) error {
+	chunks := splitMessage(embed.Description, embedDescriptionLimit)
+
+	embeds := make([]*discordgo.MessageEmbed, len(chunks))
+	for i, chunk := range chunks {
+		embeds[i] = &discordgo.MessageEmbed{Description: chunk, Color: embed.Color}
+	}
+
+	first, last := embeds[0], embeds[len(embeds)-1]
+	first.Title, first.URL, first.Author, first.Thumbnail = embed.Title, embed.URL, embed.Author, embed.Thumbnail
+	last.Fields, last.Footer, last.Timestamp, last.Image = embed.Fields, embed.Footer, embed.Timestamp, embed.Image
+
+	if err := r.edit(&discordgo.MessageSend{Embeds: []*discordgo.MessageEmbed{first}}); err != nil {
+		return err
+	}
+
+	for _, e := range embeds[1:] {
+		if err := r.send(&discordgo.MessageSend{Embeds: []*discordgo.MessageEmbed{e}}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,239 @@
+package bot
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/charmbracelet/log"
+)
+
+const (
+	// reminderAttempts is how often a reminder is sent to its channel before falling back to a DM.
+	reminderAttempts = 3
+
+	// lateReminder is how late a reminder can go off before it says so.
+	lateReminder = time.Minute
+
+	// reminderRetries is how often an occurrence that couldn't be delivered is queued again,
+	// waiting reminderBackoff longer every time, before it is given up.
+	reminderRetries = 6
+	reminderBackoff = time.Minute
+)
+
+// queuedReminder is a reminder waiting for the time it had when it was queued. Reminders
+// that were removed or moved since then are skipped, instead of being searched for in the queue.
+type queuedReminder struct {
+	reminder *Reminder
+	time     int64
+}
+
+// reminderQueue is a min-heap of reminders by time.
+type reminderQueue []queuedReminder
+
+func (q reminderQueue) Len() int           { return len(q) }
+func (q reminderQueue) Less(i, j int) bool { return q[i].time < q[j].time }
+func (q reminderQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+
+func (q *reminderQueue) Push(x any) {
+	*q = append(*q, x.(queuedReminder))
+}
+
+func (q *reminderQueue) Pop() any {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
+
+// scheduleReminder queues a reminder and wakes the scheduler. The caller must hold the write lock.
+func (b *Bot) scheduleReminder(reminder *Reminder) {
+	heap.Push(&b.reminderQueue, queuedReminder{reminder: reminder, time: reminder.due()})
+
+	select {
+	case b.reminderWake <- struct{}{}:
+	default:
+	}
+}
+
+// initializeReminders queues every stored reminder, including the ones that came due
+// while the bot was offline, and starts the scheduler.
+func (b *Bot) initializeReminders() {
+	b.mutex.Lock()
+	for _, reminder := range b.reminders {
+		b.scheduleReminder(reminder)
+	}
+	b.mutex.Unlock()
+
+	go b.runReminders()
+}
+
+// runReminders is the scheduler, it sleeps until the earliest reminder is due.
+func (b *Bot) runReminders() {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+		case <-b.reminderWake:
+		case <-b.ctx.Done():
+			log.Info("Shutting down reminder scheduler")
+			return
+		}
+
+		now := time.Now().Unix()
+		due := []*Reminder{}
+
+		b.mutex.Lock()
+		for len(b.reminderQueue) > 0 && b.reminderQueue[0].time <= now {
+			queued := heap.Pop(&b.reminderQueue).(queuedReminder)
+			if queued.time == queued.reminder.due() && slices.Contains(b.reminders, queued.reminder) {
+				due = append(due, queued.reminder)
+			}
+		}
+
+		wait := time.Hour
+		if len(b.reminderQueue) > 0 {
+			wait = time.Until(time.Unix(b.reminderQueue[0].time, 0))
+		}
+		b.mutex.Unlock()
+
+		for _, reminder := range due {
+			go b.fireReminder(reminder)
+		}
+
+		timer.Reset(wait)
+	}
+}
+
+// fireReminder delivers a due reminder, then queues its next occurrence or removes it.
+// Reminders that couldn't be delivered are queued again, the occurrence is not lost.
+func (b *Bot) fireReminder(reminder *Reminder) {
+	b.mutex.RLock()
+	delivery := *reminder
+	b.mutex.RUnlock()
+
+	notes := []string{}
+	if late := time.Since(time.Unix(delivery.Time, 0)); late >= lateReminder {
+		notes = append(notes, "late by "+formatDuration(late))
+	}
+
+	delivered := b.sendReminder(&delivery, notes)
+	if !delivered && b.ctx.Err() != nil {
+		// Interrupted by a shutdown, it goes off again on the next start
+		return
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if reminder.Time != delivery.Time || !slices.Contains(b.reminders, reminder) {
+		// Edited or cancelled while it was being sent
+		return
+	}
+
+	if !delivered && reminder.Retries < reminderRetries {
+		reminder.Retries++
+		reminder.RetryAt = time.Now().Add(reminderBackoff << (2 * (reminder.Retries - 1))).Unix()
+		b.dirty.reminders = true
+		b.scheduleReminder(reminder)
+		log.Warnf("Reminder %s will be sent again <t:%d>", reminder.ID, reminder.RetryAt)
+		return
+	} else if !delivered {
+		log.Errorf("Giving up on reminder %s after %d failed deliveries", reminder.ID, reminder.Retries+1)
+	}
+
+	if rule := reminder.rule(); rule != nil {
+		// Missed occurrences are delivered once, not once for every one missed
+		reminder.Time = reminder.nextTime(rule, time.Now())
+		b.rescheduleReminder(reminder)
+		return
+	}
+
+	b.reminders = slices.DeleteFunc(b.reminders, func(r *Reminder) bool { return r == reminder })
+	b.dirty.reminders = true
+}
+
+// sendReminder posts a reminder in its channel, retrying failed sends, and falls back to
+// a DM when the channel is gone or keeps failing. It reports whether the reminder arrived.
+func (b *Bot) sendReminder(reminder *Reminder, notes []string) bool {
+	for attempt := 1; ; attempt++ {
+		_, err := b.session.ChannelMessageSendComplex(reminder.ChannelID, reminderMessage(reminder, notes...))
+		if err == nil {
+			return true
+		}
+
+		if channelGone(err) || attempt == reminderAttempts {
+			log.Errorf("Failed to send reminder %s to channel %s: %v", reminder.ID, reminder.ChannelID, err)
+			break
+		}
+
+		log.Warnf("Failed to send reminder %s, retrying: %v", reminder.ID, err)
+		select {
+		case <-time.After(time.Duration(attempt*attempt) * 5 * time.Second):
+		case <-b.ctx.Done():
+			return false
+		}
+	}
+
+	dm, err := b.session.UserChannelCreate(reminder.UserID)
+	if err == nil {
+		notes = append(notes, fmt.Sprintf("I couldn't post this in <#%s>", reminder.ChannelID))
+		_, err = b.session.ChannelMessageSendComplex(dm.ID, reminderMessage(reminder, notes...))
+	}
+
+	if err != nil {
+		log.Errorf("Failed to send reminder %s by DM: %v", reminder.ID, err)
+		return false
+	}
+
+	return true
+}
+
+// channelGone reports whether a send failed because the channel was deleted or the bot lost access to it.
+func channelGone(err error) bool {
+	var restErr *discordgo.RESTError
+	if !errors.As(err, &restErr) || restErr.Message == nil {
+		return false
+	}
+
+	switch restErr.Message.Code {
+	case discordgo.ErrCodeUnknownChannel, discordgo.ErrCodeMissingAccess, discordgo.ErrCodeMissingPermissions:
+		return true
+	}
+
+	return false
+}
+
+// formatDuration writes a duration in its two largest units, like "2d 3h" or "5m".
+func formatDuration(d time.Duration) string {
+	units := []struct {
+		suffix string
+		size   time.Duration
+	}{
+		{"d", 24 * time.Hour},
+		{"h", time.Hour},
+		{"m", time.Minute},
+	}
+
+	parts := []string{}
+	for _, unit := range units {
+		if n := d / unit.size; n > 0 && len(parts) < 2 {
+			parts = append(parts, fmt.Sprintf("%d%s", n, unit.suffix))
+			d -= n * unit.size
+		} else if len(parts) > 0 {
+			break
+		}
+	}
+
+	if len(parts) == 0 {
+		return "less than a minute"
+	}
+
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,100 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"wherd.dev/chad/internal/cache"
+	"wherd.dev/chad/internal/config"
+	"wherd.dev/chad/internal/tools"
+	"wherd.dev/chad/internal/webpage"
+	"wherd.dev/chad/internal/websearch"
+)
+
+// newSearchProvider builds the configured search providers, falling back to Brave with the
+// search_api key, and caches their results. It returns nil when there is nothing to search with.
+func newSearchProvider(cfg *config.Config, results *cache.Cache[[]*websearch.WebResult]) websearch.Provider {
+	providers := websearch.Failover{}
+	for _, provider := range cfg.Search.Providers {
+		switch provider.Type {
+		case "brave":
+			providers = append(providers, &websearch.Brave{APIKey: provider.APIKey, BaseURL: provider.URL})
+		case "searxng":
+			providers = append(providers, &websearch.SearXNG{BaseURL: provider.URL})
+		case "json":
+			header := http.Header{}
+			for key, value := range provider.Headers {
+				header.Set(key, value)
+			}
+
+			providers = append(providers, &websearch.JSON{
+				Endpoint: provider.URL,
+				Header:   header,
+				Results:  provider.Results,
+				Fields: websearch.Fields{
+					Title:       provider.Fields.Title,
+					URL:         provider.Fields.URL,
+					Description: provider.Fields.Description,
+				},
+			})
+		default:
+			log.Errorf("Unknown search provider type %q", provider.Type)
+		}
+	}
+
+	if len(providers) == 0 && cfg.SearchApiKey != "" {
+		providers = append(providers, &websearch.Brave{APIKey: cfg.SearchApiKey})
+	}
+
+	var provider websearch.Provider
+	switch len(providers) {
+	case 0:
+		return nil
+	case 1:
+		provider = providers[0]
+	default:
+		provider = providers
+	}
+
+	if cfg.Cache.Search.Size > 0 {
+		provider = &websearch.Cached{Provider: provider, Cache: results}
+	}
+
+	return provider
+}
+
+func newPageFetcher(cfg config.Fetch) *webpage.Fetcher {
+	return webpage.New(webpage.Options{
+		MaxBytes: cfg.MaxBytes,
+		MaxChars: cfg.MaxChars,
+		Timeout:  time.Duration(cfg.Timeout) * time.Second,
+		CacheTTL: time.Duration(cfg.CacheTTL) * time.Second,
+	})
+}
+
+// searchOptions returns the configured search options.
+func (b *Bot) searchOptions() websearch.Options {
+	return websearch.Options{
+		Count:      b.config.Search.Count,
+		SafeSearch: b.config.Search.SafeSearch,
+		Locale:     b.config.Search.Locale,
+	}
+}
+
+// webSearch searches with the configured providers and reads the pages of the top results.
+func (b *Bot) webSearch(ctx context.Context, query string) ([]*websearch.WebResult, error) {
+	if b.search == nil {
+		return nil, errors.New("web search is not configured")
+	}
+
+	results, err := b.search.Search(ctx, query, b.searchOptions())
+	if err != nil {
+		return nil, err
+	}
+
+	tools.FetchPages(ctx, b.pages, results, b.config.Search.Fetch.Pages)
+	return results, nil
+}
@@ -1,78 +1,178 @@
 package bot
 
 import (
-	"encoding/json"
-	"os"
-	"time"
-
 	"github.com/charmbracelet/log"
 )
 
-// The version of the data format. If this changes, the data is considered incompatible and a new file is created.
-const dataVersion = "1.0"
-
+// Settings is the layout of the JSON data file.
 type Settings struct {
-	Timestamp       int64       `json:"timestamp"`
-	Version         string      `json:"version"`
-	Reminders       []*Reminder `json:"reminders"`
-	ReminderCounter int64       `json:"reminder_counter"`
+	Timestamp       int64                      `json:"timestamp"`
+	Version         string                     `json:"version"`
+	Reminders       []*Reminder                `json:"reminders"`
+	ReminderCounter int64                      `json:"reminder_counter"`
+	Guilds          map[string]*GuildSettings  `json:"guilds,omitempty"`
+	Users           map[string]*UserSettings   `json:"users,omitempty"`
+	History         map[string][]*HistoryEntry `json:"history,omitempty"`
+	Summaries       map[string]*Summary        `json:"summaries,omitempty"`
+	RateLimits      map[string][]int64         `json:"rate_limits,omitempty"`
+	Offenses        map[string]*Offense        `json:"offenses,omitempty"`
+	Usage           map[string]*Usage          `json:"usage,omitempty"`
 }
 
+// saveSettings writes everything that changed since the last save to the store.
 func (b *Bot) saveSettings() error {
-	b.mutex.RLock()
-	settings := Settings{
-		Timestamp:       time.Now().Unix(),
-		Version:         dataVersion,
-		Reminders:       b.reminders,
-		ReminderCounter: b.reminderCounter,
+	if b.store == nil {
+		return nil
 	}
+
+	b.saveMutex.Lock()
+	defer b.saveMutex.Unlock()
+
+	b.mutex.Lock()
+	b.pruneHistory()
+	b.pruneSummaries()
+	b.pruneOffenses()
+	b.pruneUsage()
+	dirty := b.dirty
+	b.dirty = newDirtyState()
+	b.mutex.Unlock()
+
+	b.mutex.RLock()
+	err := b.writeChanges(dirty)
 	b.mutex.RUnlock()
 
-	jsondata, err := json.MarshalIndent(settings, "", "  ")
 	if err != nil {
-		return err
+		// Try again on the next save
+		b.mutex.Lock()
+		b.dirty.merge(dirty)
+		b.mutex.Unlock()
 	}
 
-	tempFile := "chad_memory.json.tmp"
-	if err := os.WriteFile(tempFile, jsondata, 0644); err != nil {
-		return err
+	return err
+}
+
+// writeChanges saves the dirty state. The caller must hold the read lock.
+func (b *Bot) writeChanges(dirty dirtyState) error {
+	if dirty.reminders {
+		if err := b.store.SaveReminders(b.reminders, b.reminderCounter); err != nil {
+			return err
+		}
 	}
 
-	if err := os.Rename(tempFile, "chad_memory.json"); err != nil {
-		os.Remove(tempFile)
-		return err
+	for guildID := range dirty.guilds {
+		if err := b.store.SaveGuildSettings(guildID, b.guildSettings[guildID]); err != nil {
+			return err
+		}
 	}
 
-	return nil
+	for userID := range dirty.users {
+		if err := b.store.SaveUserSettings(userID, b.userSettings[userID]); err != nil {
+			return err
+		}
+	}
+
+	for channelID := range dirty.channels {
+		if err := b.store.SaveHistory(channelID, b.messageHistory[channelID]); err != nil {
+			return err
+		}
+		if err := b.store.SaveSummary(channelID, b.summaries[channelID]); err != nil {
+			return err
+		}
+	}
+
+	if dirty.rateLimits {
+		if err := b.store.SaveRateLimits(b.limiter.Snapshot()); err != nil {
+			return err
+		}
+	}
+
+	if dirty.offenses {
+		if err := b.store.SaveOffenses(b.offenses); err != nil {
+			return err
+		}
+	}
+
+	if dirty.usage {
+		if err := b.store.SaveUsage(b.usage); err != nil {
+			return err
+		}
+	}
+
+	return b.store.Flush()
 }
 
 func (b *Bot) loadSettings() error {
-	data := Settings{}
+	reminders, counter, err := b.store.LoadReminders()
+	if err != nil {
+		return err
+	}
 
-	jsondata, err := os.ReadFile("chad_memory.json")
+	guilds, err := b.store.LoadGuildSettings()
 	if err != nil {
 		return err
 	}
 
-	if err := json.Unmarshal(jsondata, &data); err != nil {
+	users, err := b.store.LoadUserSettings()
+	if err != nil {
 		return err
 	}
 
-	if time.Now().Unix()-data.Timestamp > 60*60*24*7 {
-		log.Warnf("Data is too old, starting fresh")
-		return nil
+	history, err := b.store.LoadHistory()
+	if err != nil {
+		return err
 	}
 
-	if data.Version != dataVersion {
-		log.Warnf("Data version mismatch, starting fresh")
-		return nil
+	summaries, err := b.store.LoadSummaries()
+	if err != nil {
+		return err
+	}
+
+	rateLimits, err := b.store.LoadRateLimits()
+	if err != nil {
+		return err
+	}
+
+	offenses, err := b.store.LoadOffenses()
+	if err != nil {
+		return err
+	}
+
+	usage, err := b.store.LoadUsage()
+	if err != nil {
+		return err
 	}
 
 	b.mutex.Lock()
-	b.reminders = data.Reminders
-	b.reminderCounter = data.ReminderCounter
+	if reminders != nil {
+		b.reminders = reminders
+	}
+	b.reminderCounter = counter
+	if guilds != nil {
+		b.guildSettings = guilds
+	}
+	if users != nil {
+		b.userSettings = users
+	}
+	if history != nil {
+		b.messageHistory = history
+		b.pruneHistory()
+	}
+	if summaries != nil {
+		b.summaries = summaries
+		b.pruneSummaries()
+	}
+	if offenses != nil {
+		b.offenses = offenses
+	}
+	if usage != nil {
+		b.usage = usage
+	}
 	b.mutex.Unlock()
 
-	log.Debugf("Loaded data from %s (version %s)", time.Unix(data.Timestamp, 0).Format("2006-01-02 15:04:05"), data.Version)
+	if rateLimits != nil {
+		b.limiter.Restore(rateLimits)
+	}
+
+	log.Debugf("Loaded %d reminders, %d servers and %d channels", len(reminders), len(guilds), len(history))
 	return nil
 }
@@ -0,0 +1,198 @@
+package bot
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	messageLimit          = 2000
+	embedDescriptionLimit = 4096
+)
+
+// splitMessage splits markdown into chunks of at most limit characters. It prefers
+// paragraph boundaries and the end of code blocks, then line boundaries, and only
+// breaks lines that don't fit in a chunk on their own. Code blocks cut in half are
+// closed at the end of a chunk and reopened, with their language, in the next one.
+func splitMessage(content string, limit int) []string {
+	content = strings.TrimSpace(content)
+	if utf8.RuneCountInString(content) <= limit {
+		return []string{content}
+	}
+
+	s := &splitter{limit: limit}
+	for _, line := range strings.Split(content, "\n") {
+		for _, piece := range breakLine(line, limit/2) {
+			s.add(piece)
+		}
+	}
+	s.flush(len(s.lines))
+
+	return s.chunks
+}
+
+type splitter struct {
+	limit  int
+	chunks []string
+
+	lines  []string // Lines of the current chunk
+	fences []string // Code fence open after each line, empty outside code blocks
+	size   int
+}
+
+// maxFenceLanguage bounds the language kept on a reopened code fence, so the fence
+// always leaves room for the line after it.
+const maxFenceLanguage = 32
+
+func (s *splitter) add(line string) {
+	fence := s.fenceAfter(len(s.lines))
+	if f := codeFence(line); f != "" {
+		if fence == "" {
+			fence = f
+		} else if strings.HasPrefix(f, fenceTicks(fence)) {
+			fence = ""
+		}
+	}
+
+	for len(s.lines) > 0 && s.size+1+utf8.RuneCountInString(line)+fenceReserve(fence) > s.limit {
+		// Only a reopened code fence is left, the line fits after it
+		if len(s.lines) == 1 && s.fences[0] != "" && s.lines[0] == s.fences[0] {
+			break
+		}
+
+		s.flush(s.splitPoint())
+	}
+
+	if len(s.lines) > 0 {
+		s.size++
+	}
+	s.lines = append(s.lines, line)
+	s.fences = append(s.fences, fence)
+	s.size += utf8.RuneCountInString(line)
+}
+
+// splitPoint returns how many lines of the current chunk to emit. It looks for the
+// last paragraph break or closed code block in the second half of the chunk.
+func (s *splitter) splitPoint() int {
+	for n := len(s.lines) - 1; n >= len(s.lines)/2 && n > 0; n-- {
+		if s.fences[n-1] != "" {
+			continue
+		}
+
+		if s.lines[n] == "" || strings.HasPrefix(strings.TrimSpace(s.lines[n-1]), "```") {
+			return n
+		}
+	}
+
+	return len(s.lines)
+}
+
+// flush emits the first n lines as a chunk and keeps the rest for the next one.
+func (s *splitter) flush(n int) {
+	fence := s.fenceAfter(n)
+
+	chunk := strings.TrimSpace(strings.Join(s.lines[:n], "\n"))
+	if fence != "" {
+		chunk += "\n" + fenceTicks(fence)
+	}
+	if chunk != "" {
+		s.chunks = append(s.chunks, chunk)
+	}
+
+	rest := s.lines[n:]
+	restFences := s.fences[n:]
+	if len(rest) > 0 && rest[0] == "" {
+		rest, restFences = rest[1:], restFences[1:]
+	}
+
+	s.lines, s.fences, s.size = nil, nil, 0
+	if fence != "" {
+		s.lines = append(s.lines, fence)
+		s.fences = append(s.fences, fence)
+		s.size = utf8.RuneCountInString(fence)
+	}
+
+	for i, line := range rest {
+		if len(s.lines) > 0 {
+			s.size++
+		}
+		s.lines = append(s.lines, line)
+		s.fences = append(s.fences, restFences[i])
+		s.size += utf8.RuneCountInString(line)
+	}
+}
+
+// fenceAfter returns the code fence open after the first n lines of the current chunk.
+func (s *splitter) fenceAfter(n int) string {
+	if n == 0 {
+		return ""
+	}
+	return s.fences[n-1]
+}
+
+// fenceReserve is the room left for closing the code fence at the end of a chunk.
+func fenceReserve(fence string) int {
+	if fence == "" {
+		return 0
+	}
+	return 1 + len(fenceTicks(fence))
+}
+
+// codeFence returns the backticks and language of a line that opens or closes a code block.
+// It returns "" for other lines, including code blocks that close on the line they open.
+func codeFence(line string) string {
+	trimmed := strings.TrimSpace(line)
+	ticks := fenceTicks(trimmed)
+	if len(ticks) < 3 {
+		return ""
+	}
+
+	info := trimmed[len(ticks):]
+	if strings.Contains(info, "`") {
+		return ""
+	}
+
+	if language := strings.Fields(info); len(language) > 0 && len(language[0]) <= maxFenceLanguage {
+		return ticks + language[0]
+	}
+	return ticks
+}
+
+// fenceTicks returns the backticks a fence starts with.
+func fenceTicks(fence string) string {
+	return fence[:len(fence)-len(strings.TrimLeft(fence, "`"))]
+}
+
+// breakLine cuts a line into pieces of at most max characters, preferring spaces.
+func breakLine(line string, max int) []string {
+	runes := []rune(line)
+	pieces := []string{}
+
+	for len(runes) > max {
+		cut := max
+		for i := max; i > max/2; i-- {
+			if runes[i] == ' ' {
+				cut = i
+				break
+			}
+		}
+
+		pieces = append(pieces, string(runes[:cut]))
+		runes = runes[cut:]
+		if len(runes) > 0 && runes[0] == ' ' {
+			runes = runes[1:]
+		}
+	}
+
+	return append(pieces, string(runes))
+}
+
+// truncate shortens text to at most limit runes, marking the cut with an ellipsis.
+func truncate(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+
+	return string(runes[:limit-1]) + "…"
+}
@@ -0,0 +1,161 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// words returns the words of markdown without its code fences, to compare content across chunks.
+func words(content string) []string {
+	list := []string{}
+	for _, word := range strings.Fields(content) {
+		if !strings.HasPrefix(word, "```") {
+			list = append(list, word)
+		}
+	}
+	return list
+}
+
+func TestSplitMessage(t *testing.T) {
+	paragraph := strings.TrimSpace(strings.Repeat("Lorem ipsum dolor sit amet. ", 20))
+	code := func(lines int) string {
+		list := make([]string, lines)
+		for i := range list {
+			list[i] = fmt.Sprintf("    fmt.Println(%d) // Print the number", i)
+		}
+		return strings.Join(list, "\n")
+	}
+
+	tests := []struct {
+		name    string
+		content string
+		limit   int
+		chunks  int // 0 to not check the count
+	}{
+		{
+			name:    "short",
+			content: "Hello!",
+			limit:   messageLimit,
+			chunks:  1,
+		},
+		{
+			name:    "paragraphs",
+			content: strings.Repeat(paragraph+"\n\n", 10),
+			limit:   messageLimit,
+		},
+		{
+			name:    "one long line",
+			content: strings.Repeat("word ", 1000),
+			limit:   messageLimit,
+		},
+		{
+			name:    "long word",
+			content: strings.Repeat("x", 5000),
+			limit:   messageLimit,
+			chunks:  5,
+		},
+		{
+			name:    "code block",
+			content: "Here you go:\n```go\n" + code(100) + "\n```\nDone.",
+			limit:   messageLimit,
+		},
+		{
+			name:    "code block with long lines",
+			content: "```\n" + strings.Repeat(strings.Repeat("y = x ", 300)+"\n", 5) + "```",
+			limit:   messageLimit,
+		},
+		{
+			name:    "text on the opening fence",
+			content: "```go " + strings.Repeat("fmt.Println() ", 130) + "\n" + code(60) + "\n```",
+			limit:   messageLimit,
+		},
+		{
+			name:    "one line code blocks",
+			content: strings.Repeat("Run ```python print(1)``` and then "+strings.Repeat("more text ", 20)+"\n", 30),
+			limit:   messageLimit,
+		},
+		{
+			name:    "one line code block before a code block",
+			content: "```python print(1)```\n" + paragraph + "\n```js\n" + code(80) + "\n```\n" + paragraph,
+			limit:   messageLimit,
+		},
+		{
+			name:    "fence with four backticks",
+			content: "````markdown\n```go\n" + code(40) + "\n```\n" + code(40) + "\n````",
+			limit:   messageLimit,
+		},
+		{
+			name:    "long language",
+			content: "```" + strings.Repeat("l", 900) + "\n" + code(80) + "\n```",
+			limit:   messageLimit,
+		},
+		{
+			name:    "multibyte characters",
+			content: strings.Repeat("héllo wörld ✨ ", 400),
+			limit:   messageLimit,
+		},
+		{
+			name:    "embed description",
+			content: "```\n" + code(300) + "\n```",
+			limit:   embedDescriptionLimit,
+		},
+		{
+			name:    "small limit",
+			content: "```go\n" + code(30) + "\n```\n\n" + paragraph,
+			limit:   100,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunks := splitMessage(tt.content, tt.limit)
+			if tt.chunks > 0 && len(chunks) != tt.chunks {
+				t.Errorf("split into %d chunks, want %d", len(chunks), tt.chunks)
+			}
+
+			for i, chunk := range chunks {
+				if size := utf8.RuneCountInString(chunk); size > tt.limit {
+					t.Errorf("chunk %d has %d characters, over the limit of %d", i, size, tt.limit)
+				}
+				if chunk == "" {
+					t.Errorf("chunk %d is empty", i)
+				}
+				if fences := strings.Count(chunk, "```") - strings.Count(chunk, "````"); fences%2 != 0 {
+					t.Errorf("chunk %d has unbalanced code fences:\n%s", i, chunk)
+				}
+			}
+
+			// Words too long for a chunk are cut in pieces
+			got, want := words(strings.Join(chunks, "\n")), words(tt.content)
+			if strings.Join(got, "") != strings.Join(want, "") {
+				t.Errorf("the chunks have %d words, want the %d of the message in order", len(got), len(want))
+			}
+		})
+	}
+}
+
+func TestCodeFence(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{"```", "```"},
+		{"```go", "```go"},
+		{"  ```python  ", "```python"},
+		{"```go func main() {}", "```go"},
+		{"````markdown", "````markdown"},
+		{"```python print(1)```", ""},
+		{"```a` b", ""},
+		{"``not a fence", ""},
+		{"text ```go", ""},
+		{"```" + strings.Repeat("l", 100), "```"},
+	}
+
+	for _, tt := range tests {
+		if got := codeFence(tt.line); got != tt.want {
+			t.Errorf("codeFence(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package bot
+
+import (
+	"fmt"
+
+	"wherd.dev/chad/internal/config"
+)
+
+// Store persists the bot state. Save methods may buffer their changes until Flush.
+type Store interface {
+	LoadReminders() ([]*Reminder, int64, error)
+	SaveReminders(reminders []*Reminder, counter int64) error
+
+	LoadGuildSettings() (map[string]*GuildSettings, error)
+	SaveGuildSettings(guildID string, settings *GuildSettings) error
+
+	LoadUserSettings() (map[string]*UserSettings, error)
+	SaveUserSettings(userID string, settings *UserSettings) error
+
+	LoadHistory() (map[string][]*HistoryEntry, error)
+	SaveHistory(channelID string, history []*HistoryEntry) error
+
+	LoadSummaries() (map[string]*Summary, error)
+	SaveSummary(channelID string, summary *Summary) error
+
+	LoadRateLimits() (map[string][]int64, error)
+	SaveRateLimits(rateLimits map[string][]int64) error
+
+	LoadOffenses() (map[string]*Offense, error)
+	SaveOffenses(offenses map[string]*Offense) error
+
+	LoadUsage() (map[string]*Usage, error)
+	SaveUsage(usage map[string]*Usage) error
+
+	Flush() error
+	Close() error
+}
+
+func openStore(cfg config.Storage) (Store, error) {
+	switch cfg.Driver {
+	case "", "json":
+		path := cfg.Path
+		if path == "" {
+			path = "chad_memory.json"
+		}
+		return openJSONStore(path)
+	case "sqlite":
+		path := cfg.Path
+		if path == "" {
+			path = "chad.db"
+		}
+		return openSQLiteStore(path)
+	}
+
+	return nil, fmt.Errorf("unknown storage driver %q", cfg.Driver)
+}
+
+// dirtyState tracks what changed since the last save, so only that is written.
+type dirtyState struct {
+	reminders  bool
+	rateLimits bool
+	offenses   bool
+	usage      bool
+	guilds     map[string]bool
+	users      map[string]bool
+	channels   map[string]bool
+}
+
+func newDirtyState() dirtyState {
+	return dirtyState{
+		guilds:   map[string]bool{},
+		users:    map[string]bool{},
+		channels: map[string]bool{},
+	}
+}
+
+func (d *dirtyState) merge(other dirtyState) {
+	d.reminders = d.reminders || other.reminders
+	d.rateLimits = d.rateLimits || other.rateLimits
+	d.offenses = d.offenses || other.offenses
+	d.usage = d.usage || other.usage
+	for guildID := range other.guilds {
+		d.guilds[guildID] = true
+	}
+	for userID := range other.users {
+		d.users[userID] = true
+	}
+	for channelID := range other.channels {
+		d.channels[channelID] = true
+	}
+}
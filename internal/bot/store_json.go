@@ -0,0 +1,209 @@
+package bot
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"maps"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"wherd.dev/chad/internal/migrate"
+)
+
+// jsonStore keeps the whole state in a single JSON file, rewritten on Flush when something changed.
+// It keeps its own copies of the bot's maps and slices, but the values in them are shared, so Flush
+// and Close need the bot's read lock.
+type jsonStore struct {
+	path     string
+	settings Settings
+	changed  bool
+}
+
+func openJSONStore(path string) (*jsonStore, error) {
+	s := &jsonStore{
+		path: path,
+		settings: Settings{
+			Version: migrate.Latest(),
+		},
+	}
+
+	// Older files are upgraded in place, keeping a backup of the original
+	applied, backup, err := migrate.MigrateFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	for _, step := range applied {
+		log.Infof("Migrated data from version %s to %s", step.From, step.To)
+	}
+	if backup != "" {
+		log.Infof("Previous data saved to %s", backup)
+	}
+
+	jsondata, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	data := Settings{}
+	if err := json.Unmarshal(jsondata, &data); err != nil {
+		return nil, err
+	}
+
+	log.Debugf("Loaded data from %s (version %s)", time.Unix(data.Timestamp, 0).Format("2006-01-02 15:04:05"), data.Version)
+	s.settings = data
+	return s, nil
+}
+
+func (s *jsonStore) LoadReminders() ([]*Reminder, int64, error) {
+	return s.settings.Reminders, s.settings.ReminderCounter, nil
+}
+
+func (s *jsonStore) SaveReminders(reminders []*Reminder, counter int64) error {
+	s.settings.Reminders = slices.Clone(reminders)
+	s.settings.ReminderCounter = counter
+	s.changed = true
+	return nil
+}
+
+func (s *jsonStore) LoadGuildSettings() (map[string]*GuildSettings, error) {
+	return s.settings.Guilds, nil
+}
+
+func (s *jsonStore) SaveGuildSettings(guildID string, settings *GuildSettings) error {
+	if s.settings.Guilds == nil {
+		s.settings.Guilds = map[string]*GuildSettings{}
+	}
+
+	if settings == nil {
+		delete(s.settings.Guilds, guildID)
+	} else {
+		s.settings.Guilds[guildID] = settings
+	}
+
+	s.changed = true
+	return nil
+}
+
+func (s *jsonStore) LoadUserSettings() (map[string]*UserSettings, error) {
+	return s.settings.Users, nil
+}
+
+func (s *jsonStore) SaveUserSettings(userID string, settings *UserSettings) error {
+	if s.settings.Users == nil {
+		s.settings.Users = map[string]*UserSettings{}
+	}
+
+	if settings == nil {
+		delete(s.settings.Users, userID)
+	} else {
+		s.settings.Users[userID] = settings
+	}
+
+	s.changed = true
+	return nil
+}
+
+func (s *jsonStore) LoadHistory() (map[string][]*HistoryEntry, error) {
+	return s.settings.History, nil
+}
+
+func (s *jsonStore) SaveHistory(channelID string, history []*HistoryEntry) error {
+	if s.settings.History == nil {
+		s.settings.History = map[string][]*HistoryEntry{}
+	}
+
+	if len(history) == 0 {
+		delete(s.settings.History, channelID)
+	} else {
+		s.settings.History[channelID] = history
+	}
+
+	s.changed = true
+	return nil
+}
+
+func (s *jsonStore) LoadSummaries() (map[string]*Summary, error) {
+	return s.settings.Summaries, nil
+}
+
+func (s *jsonStore) SaveSummary(channelID string, summary *Summary) error {
+	if s.settings.Summaries == nil {
+		s.settings.Summaries = map[string]*Summary{}
+	}
+
+	if summary == nil {
+		delete(s.settings.Summaries, channelID)
+	} else {
+		s.settings.Summaries[channelID] = summary
+	}
+
+	s.changed = true
+	return nil
+}
+
+func (s *jsonStore) LoadRateLimits() (map[string][]int64, error) {
+	return s.settings.RateLimits, nil
+}
+
+func (s *jsonStore) SaveRateLimits(rateLimits map[string][]int64) error {
+	s.settings.RateLimits = rateLimits
+	s.changed = true
+	return nil
+}
+
+func (s *jsonStore) LoadOffenses() (map[string]*Offense, error) {
+	return s.settings.Offenses, nil
+}
+
+func (s *jsonStore) SaveOffenses(offenses map[string]*Offense) error {
+	s.settings.Offenses = maps.Clone(offenses)
+	s.changed = true
+	return nil
+}
+
+func (s *jsonStore) LoadUsage() (map[string]*Usage, error) {
+	return s.settings.Usage, nil
+}
+
+func (s *jsonStore) SaveUsage(usage map[string]*Usage) error {
+	s.settings.Usage = maps.Clone(usage)
+	s.changed = true
+	return nil
+}
+
+func (s *jsonStore) Flush() error {
+	if !s.changed {
+		return nil
+	}
+
+	s.settings.Timestamp = time.Now().Unix()
+	jsondata, err := json.MarshalIndent(s.settings, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tempFile := s.path + ".tmp"
+	if err := os.WriteFile(tempFile, jsondata, 0644); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tempFile, s.path); err != nil {
+		os.Remove(tempFile)
+		return err
+	}
+
+	s.changed = false
+	return nil
+}
+
+func (s *jsonStore) Close() error {
+	// Always refresh the timestamp on shutdown so it tells when the bot last ran
+	s.changed = true
+	return s.Flush()
+}
@@ -0,0 +1,469 @@
+package bot
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strconv"
+
+	"github.com/charmbracelet/log"
+	_ "modernc.org/sqlite"
+	"wherd.dev/chad/internal/migrate"
+)
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS meta (
+	key   TEXT PRIMARY KEY,
+	value TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS reminders (
+	id   TEXT PRIMARY KEY,
+	data TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS guilds (
+	guild_id TEXT PRIMARY KEY,
+	data     TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS users (
+	user_id TEXT PRIMARY KEY,
+	data    TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS history (
+	channel_id TEXT NOT NULL,
+	position   INTEGER NOT NULL,
+	message_id TEXT NOT NULL,
+	author_id  TEXT NOT NULL,
+	role       TEXT NOT NULL,
+	content    TEXT NOT NULL,
+	timestamp  INTEGER NOT NULL,
+	PRIMARY KEY (channel_id, position)
+);
+
+CREATE TABLE IF NOT EXISTS summaries (
+	channel_id TEXT PRIMARY KEY,
+	data       TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS rate_limits (
+	key        TEXT PRIMARY KEY,
+	timestamps TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS offenses (
+	key  TEXT PRIMARY KEY,
+	data TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS usage (
+	key  TEXT PRIMARY KEY,
+	data TEXT NOT NULL
+);
+`
+
+// sqliteStore writes every change straight to an SQLite database.
+type sqliteStore struct {
+	db *sql.DB
+}
+
+func openSQLiteStore(path string) (*sqliteStore, error) {
+	db, err := sql.Open("sqlite", path+"?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, err
+	}
+
+	// SQLite allows a single writer, sharing one connection avoids busy errors
+	db.SetMaxOpenConns(1)
+
+	if err := migrateSQLite(db, path); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &sqliteStore{db: db}, nil
+}
+
+// migrateSQLite upgrades an existing database to the latest schema version, then creates
+// the tables it doesn't have yet.
+func migrateSQLite(db *sql.DB, path string) error {
+	version, err := migrate.SQLVersion(db)
+	if err != nil {
+		return err
+	}
+
+	applied, backup, err := migrate.MigrateSQL(db, path)
+	for _, step := range applied {
+		log.Infof("Migrated database from version %s to %s", step.From, step.To)
+	}
+	if backup != "" {
+		log.Infof("Previous database saved to %s", backup)
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		return err
+	}
+
+	if version == "" {
+		return migrate.SetSQLVersion(db, migrate.LatestSQL())
+	}
+	return nil
+}
+
+func (s *sqliteStore) LoadReminders() ([]*Reminder, int64, error) {
+	reminders := []*Reminder{}
+	err := s.query("SELECT data FROM reminders", func(rows *sql.Rows) error {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return err
+		}
+
+		reminder := &Reminder{}
+		if err := json.Unmarshal([]byte(data), reminder); err != nil {
+			return err
+		}
+
+		reminders = append(reminders, reminder)
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var counter string
+	err = s.db.QueryRow("SELECT value FROM meta WHERE key = 'reminder_counter'").Scan(&counter)
+	if err == sql.ErrNoRows {
+		return reminders, 0, nil
+	} else if err != nil {
+		return nil, 0, err
+	}
+
+	n, err := strconv.ParseInt(counter, 10, 64)
+	return reminders, n, err
+}
+
+func (s *sqliteStore) SaveReminders(reminders []*Reminder, counter int64) error {
+	return s.transaction(func(tx *sql.Tx) error {
+		if _, err := tx.Exec("DELETE FROM reminders"); err != nil {
+			return err
+		}
+
+		for _, reminder := range reminders {
+			data, err := json.Marshal(reminder)
+			if err != nil {
+				return err
+			}
+
+			if _, err := tx.Exec("INSERT INTO reminders (id, data) VALUES (?, ?)", reminder.ID, string(data)); err != nil {
+				return err
+			}
+		}
+
+		_, err := tx.Exec("INSERT OR REPLACE INTO meta (key, value) VALUES ('reminder_counter', ?)", strconv.FormatInt(counter, 10))
+		return err
+	})
+}
+
+func (s *sqliteStore) LoadGuildSettings() (map[string]*GuildSettings, error) {
+	guilds := map[string]*GuildSettings{}
+	err := s.query("SELECT guild_id, data FROM guilds", func(rows *sql.Rows) error {
+		var guildID, data string
+		if err := rows.Scan(&guildID, &data); err != nil {
+			return err
+		}
+
+		settings := &GuildSettings{}
+		if err := json.Unmarshal([]byte(data), settings); err != nil {
+			return err
+		}
+
+		guilds[guildID] = settings
+		return nil
+	})
+
+	return guilds, err
+}
+
+func (s *sqliteStore) SaveGuildSettings(guildID string, settings *GuildSettings) error {
+	if settings == nil {
+		_, err := s.db.Exec("DELETE FROM guilds WHERE guild_id = ?", guildID)
+		return err
+	}
+
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.Exec("INSERT OR REPLACE INTO guilds (guild_id, data) VALUES (?, ?)", guildID, string(data))
+	return err
+}
+
+func (s *sqliteStore) LoadUserSettings() (map[string]*UserSettings, error) {
+	users := map[string]*UserSettings{}
+	err := s.query("SELECT user_id, data FROM users", func(rows *sql.Rows) error {
+		var userID, data string
+		if err := rows.Scan(&userID, &data); err != nil {
+			return err
+		}
+
+		settings := &UserSettings{}
+		if err := json.Unmarshal([]byte(data), settings); err != nil {
+			return err
+		}
+
+		users[userID] = settings
+		return nil
+	})
+
+	return users, err
+}
+
+func (s *sqliteStore) SaveUserSettings(userID string, settings *UserSettings) error {
+	if settings == nil {
+		_, err := s.db.Exec("DELETE FROM users WHERE user_id = ?", userID)
+		return err
+	}
+
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.Exec("INSERT OR REPLACE INTO users (user_id, data) VALUES (?, ?)", userID, string(data))
+	return err
+}
+
+func (s *sqliteStore) LoadHistory() (map[string][]*HistoryEntry, error) {
+	history := map[string][]*HistoryEntry{}
+	err := s.query("SELECT channel_id, message_id, author_id, role, content, timestamp FROM history ORDER BY channel_id, position", func(rows *sql.Rows) error {
+		var channelID string
+		entry := &HistoryEntry{}
+		if err := rows.Scan(&channelID, &entry.MessageID, &entry.AuthorID, &entry.Role, &entry.Content, &entry.Timestamp); err != nil {
+			return err
+		}
+
+		history[channelID] = append(history[channelID], entry)
+		return nil
+	})
+
+	return history, err
+}
+
+func (s *sqliteStore) SaveHistory(channelID string, history []*HistoryEntry) error {
+	return s.transaction(func(tx *sql.Tx) error {
+		if _, err := tx.Exec("DELETE FROM history WHERE channel_id = ?", channelID); err != nil {
+			return err
+		}
+
+		for i, entry := range history {
+			_, err := tx.Exec("INSERT INTO history (channel_id, position, message_id, author_id, role, content, timestamp) VALUES (?, ?, ?, ?, ?, ?, ?)",
+				channelID, i, entry.MessageID, entry.AuthorID, entry.Role, entry.Content, entry.Timestamp)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (s *sqliteStore) LoadSummaries() (map[string]*Summary, error) {
+	summaries := map[string]*Summary{}
+	err := s.query("SELECT channel_id, data FROM summaries", func(rows *sql.Rows) error {
+		var channelID, data string
+		if err := rows.Scan(&channelID, &data); err != nil {
+			return err
+		}
+
+		summary := &Summary{}
+		if err := json.Unmarshal([]byte(data), summary); err != nil {
+			return err
+		}
+
+		summaries[channelID] = summary
+		return nil
+	})
+
+	return summaries, err
+}
+
+func (s *sqliteStore) SaveSummary(channelID string, summary *Summary) error {
+	if summary == nil {
+		_, err := s.db.Exec("DELETE FROM summaries WHERE channel_id = ?", channelID)
+		return err
+	}
+
+	data, err := json.Marshal(summary)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.Exec("INSERT OR REPLACE INTO summaries (channel_id, data) VALUES (?, ?)", channelID, string(data))
+	return err
+}
+
+func (s *sqliteStore) LoadRateLimits() (map[string][]int64, error) {
+	rateLimits := map[string][]int64{}
+	err := s.query("SELECT key, timestamps FROM rate_limits", func(rows *sql.Rows) error {
+		var key, data string
+		if err := rows.Scan(&key, &data); err != nil {
+			return err
+		}
+
+		timestamps := []int64{}
+		if err := json.Unmarshal([]byte(data), &timestamps); err != nil {
+			return err
+		}
+
+		rateLimits[key] = timestamps
+		return nil
+	})
+
+	return rateLimits, err
+}
+
+func (s *sqliteStore) SaveRateLimits(rateLimits map[string][]int64) error {
+	return s.transaction(func(tx *sql.Tx) error {
+		if _, err := tx.Exec("DELETE FROM rate_limits"); err != nil {
+			return err
+		}
+
+		for key, timestamps := range rateLimits {
+			data, err := json.Marshal(timestamps)
+			if err != nil {
+				return err
+			}
+
+			if _, err := tx.Exec("INSERT INTO rate_limits (key, timestamps) VALUES (?, ?)", key, string(data)); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (s *sqliteStore) LoadOffenses() (map[string]*Offense, error) {
+	offenses := map[string]*Offense{}
+	err := s.query("SELECT key, data FROM offenses", func(rows *sql.Rows) error {
+		var key, data string
+		if err := rows.Scan(&key, &data); err != nil {
+			return err
+		}
+
+		offense := &Offense{}
+		if err := json.Unmarshal([]byte(data), offense); err != nil {
+			return err
+		}
+
+		offenses[key] = offense
+		return nil
+	})
+
+	return offenses, err
+}
+
+func (s *sqliteStore) SaveOffenses(offenses map[string]*Offense) error {
+	return s.transaction(func(tx *sql.Tx) error {
+		if _, err := tx.Exec("DELETE FROM offenses"); err != nil {
+			return err
+		}
+
+		for key, offense := range offenses {
+			data, err := json.Marshal(offense)
+			if err != nil {
+				return err
+			}
+
+			if _, err := tx.Exec("INSERT INTO offenses (key, data) VALUES (?, ?)", key, string(data)); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (s *sqliteStore) LoadUsage() (map[string]*Usage, error) {
+	usage := map[string]*Usage{}
+	err := s.query("SELECT key, data FROM usage", func(rows *sql.Rows) error {
+		var key, data string
+		if err := rows.Scan(&key, &data); err != nil {
+			return err
+		}
+
+		u := &Usage{}
+		if err := json.Unmarshal([]byte(data), u); err != nil {
+			return err
+		}
+
+		usage[key] = u
+		return nil
+	})
+
+	return usage, err
+}
+
+func (s *sqliteStore) SaveUsage(usage map[string]*Usage) error {
+	return s.transaction(func(tx *sql.Tx) error {
+		if _, err := tx.Exec("DELETE FROM usage"); err != nil {
+			return err
+		}
+
+		for key, u := range usage {
+			data, err := json.Marshal(u)
+			if err != nil {
+				return err
+			}
+
+			if _, err := tx.Exec("INSERT INTO usage (key, data) VALUES (?, ?)", key, string(data)); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (s *sqliteStore) Flush() error {
+	return nil
+}
+
+func (s *sqliteStore) Close() error {
+	return s.db.Close()
+}
+
+func (s *sqliteStore) query(query string, scan func(rows *sql.Rows) error) error {
+	rows, err := s.db.Query(query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func (s *sqliteStore) transaction(fn func(tx *sql.Tx) error) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
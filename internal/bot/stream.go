@@ -0,0 +1,38 @@
+package bot
+
+import (
+	"context"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"wherd.dev/chad/internal/openrouter"
+)
+
+// Discord allows roughly 5 message edits per 5 seconds in a channel.
+const streamEditInterval = 1500 * time.Millisecond
+
+// streamCompletion streams the request and calls update with the content received so
+// far, at most once every streamEditInterval.
+func streamCompletion(ctx context.Context, o *openrouter.OpenRouter, req *openrouter.Request, update func(content string)) (*openrouter.Response, error) {
+	content := &strings.Builder{}
+	lastUpdate := time.Now()
+
+	return o.Stream(ctx, req, func(delta *openrouter.Delta) {
+		if delta.Content == "" {
+			return
+		}
+
+		content.WriteString(delta.Content)
+
+		// Long replies are split once complete, the preview stops at the first message
+		if utf8.RuneCountInString(content.String()) > messageLimit-2 {
+			return
+		}
+
+		if time.Since(lastUpdate) >= streamEditInterval {
+			lastUpdate = time.Now()
+			update(content.String() + " ▌")
+		}
+	})
+}
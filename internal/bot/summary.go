@@ -0,0 +1,338 @@
+package bot
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/charmbracelet/log"
+	"wherd.dev/chad/internal/openrouter"
+)
+
+// Summary condenses the messages of a channel that fell out of the AI's context.
+type Summary struct {
+	Content   string          `json:"content"`
+	MessageID string          `json:"message_id"` // The last message summarized
+	Timestamp int64           `json:"timestamp"`  // Of the last message summarized
+	Messages  int             `json:"messages"`   // How many messages went into the summary
+	Updated   int64           `json:"updated"`
+	Dropped   []*HistoryEntry `json:"dropped,omitempty"` // Left the history before they were summarized
+}
+
+const summaryPrompt = `You keep the memory of a Discord channel. Update the summary of the conversation with the new messages.
+
+Keep:
+- Topics, decisions and questions still open
+- Who said what, by username
+- What people asked the bot to remember
+- Facts about people only when they shared them themselves
+
+Leave out greetings, small talk and anything settled that won't matter later. Write plain sentences or short bullets, at most %d words, and answer with the summary only.`
+
+// summaryTemperature keeps summaries close to what was said.
+var summaryTemperature = 0.2
+
+// Dropped messages kept for the summary while summarizing fails, like when the budget is spent.
+const maxDroppedMessages = 100
+
+// summaryBackoff is how long a channel isn't summarized again after summarizing it failed.
+const summaryBackoff = 10 * time.Minute
+
+// after returns the entries that come after the last summarized message.
+func (s *Summary) after(entries []*HistoryEntry) []*HistoryEntry {
+	if s == nil || (s.MessageID == "" && s.Timestamp == 0) {
+		return entries
+	}
+
+	for i := len(entries) - 1; i >= 0; i-- {
+		if entries[i].MessageID == s.MessageID {
+			return entries[i+1:]
+		}
+	}
+
+	// The last summarized message isn't among them
+	i := 0
+	for i < len(entries) && entries[i].Timestamp <= s.Timestamp {
+		i++
+	}
+
+	return entries[i:]
+}
+
+// unsummarized returns the messages of the channel that fell out of the AI's context and
+// aren't in its summary yet: the ones the history dropped, and the ones older than what
+// fit in the last request. The caller must hold the lock.
+func (b *Bot) unsummarized(channelID string) []*HistoryEntry {
+	summary := b.summaries[channelID]
+	history := b.messageHistory[channelID]
+
+	// Until the channel's context was built nothing is known to be left out of it
+	kept, ok := b.contextKept[channelID]
+	if !ok {
+		kept = len(history)
+	}
+	outside := history[:len(history)-min(kept, len(history))]
+
+	if summary == nil {
+		return outside
+	}
+
+	return summary.after(append(slices.Clip(summary.Dropped), outside...))
+}
+
+// keepForSummary holds on to the messages the history drops until they are summarized.
+// The caller must hold the write lock.
+func (b *Bot) keepForSummary(channelID string, dropped []*HistoryEntry) {
+	if !b.config.History.Summary.Enabled {
+		return
+	}
+
+	summary := b.summaries[channelID]
+	if summary == nil {
+		summary = &Summary{}
+		b.summaries[channelID] = summary
+	}
+
+	summary.Dropped = append(summary.Dropped, summary.after(dropped)...)
+	if len(summary.Dropped) > maxDroppedMessages {
+		summary.Dropped = slices.Clone(summary.Dropped[len(summary.Dropped)-maxDroppedMessages:])
+	}
+}
+
+// maybeSummarize starts summarizing the channel once enough messages fell out of the AI's
+// context. The caller must hold the write lock.
+func (b *Bot) maybeSummarize(guildID string, channelID string) {
+	if !b.config.History.Summary.Enabled || b.summarizing[channelID] {
+		return
+	}
+
+	if failed, ok := b.summaryFailed[channelID]; ok && time.Since(failed) < summaryBackoff {
+		return
+	}
+
+	if len(b.unsummarized(channelID)) < max(b.config.History.Summary.Batch, 1) {
+		return
+	}
+
+	b.summarizing[channelID] = true
+	go b.summarize(guildID, channelID)
+}
+
+// summarize folds the messages that fell out of the AI's context into the channel's summary.
+func (b *Bot) summarize(guildID string, channelID string) {
+	defer func() {
+		b.mutex.Lock()
+		delete(b.summarizing, channelID)
+		b.mutex.Unlock()
+	}()
+
+	if _, over := b.overBudget(guildID); over {
+		return
+	}
+
+	b.mutex.RLock()
+	pending := slices.Clone(b.unsummarized(channelID))
+	previous := Summary{}
+	if summary := b.summaries[channelID]; summary != nil {
+		previous = *summary
+	}
+	b.mutex.RUnlock()
+
+	if len(pending) == 0 {
+		return
+	}
+
+	o := b.newClient(guildID, channelID, "", taskSummary)
+	o.SystemPrompt = fmt.Sprintf(summaryPrompt, b.config.History.Summary.MaxTokens*3/4)
+	o.MaxTokens = b.config.History.Summary.MaxTokens
+	o.Temperature = &summaryTemperature
+
+	messages := make([]*openrouter.Message, len(pending))
+	for i, entry := range pending {
+		messages[i] = &openrouter.Message{Role: entry.Role, Content: entry.Content}
+	}
+
+	var req *openrouter.Request
+	for {
+		req = o.NewRequest()
+		if previous.Content != "" {
+			req.AddMessage("system", "The summary so far:\n"+previous.Content)
+		}
+		req.AddMessage("user", "Update the summary with the messages above.")
+
+		kept := o.FitContext(req, messages)
+		if kept == len(messages) {
+			break
+		}
+		if kept == 0 {
+			log.Warnf("No messages of channel %s fit in a summary request", channelID)
+			b.failSummary(channelID)
+			return
+		}
+
+		// The oldest first, the rest is left for the next summary
+		messages, pending = messages[:kept], pending[:kept]
+	}
+
+	response, err := o.Send(b.ctx, req)
+	if err != nil || len(response.Choices) == 0 {
+		log.Errorf("Failed to summarize channel %s: %v", channelID, err)
+		b.failSummary(channelID)
+		return
+	}
+
+	content := strings.TrimSpace(response.Choices[0].Message.Content)
+	if content == "" {
+		log.Warnf("Summary of channel %s came back empty", channelID)
+		b.failSummary(channelID)
+		return
+	}
+
+	last := pending[len(pending)-1]
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	delete(b.summaryFailed, channelID)
+
+	// Forgotten in the meantime
+	if current := b.summaries[channelID]; current != nil && current.MessageID != previous.MessageID {
+		return
+	}
+
+	summary := &Summary{
+		Content:   content,
+		MessageID: last.MessageID,
+		Timestamp: last.Timestamp,
+		Messages:  previous.Messages + len(pending),
+		Updated:   time.Now().Unix(),
+	}
+	if current := b.summaries[channelID]; current != nil {
+		// Dropped while the summary was written
+		summary.Dropped = slices.Clone(summary.after(current.Dropped))
+	}
+	b.summaries[channelID] = summary
+	b.dirty.channels[channelID] = true
+
+	log.Debugf("Summarized %d more messages of channel %s", len(pending), channelID)
+}
+
+// failSummary holds off summarizing the channel again for a while, so a failing request
+// isn't repeated with every new message.
+func (b *Bot) failSummary(channelID string) {
+	b.mutex.Lock()
+	b.summaryFailed[channelID] = time.Now()
+	b.mutex.Unlock()
+}
+
+// addSummary gives the request what the bot remembers of the channel beyond its context.
+// Add it before the context, it goes with the system prompt.
+func (b *Bot) addSummary(req *openrouter.Request, channelID string) {
+	b.mutex.RLock()
+	content := ""
+	if summary := b.summaries[channelID]; summary != nil {
+		content = summary.Content
+	}
+	b.mutex.RUnlock()
+
+	if content != "" {
+		req.AddMessage("system", "Summary of the earlier conversation in this channel:\n"+content)
+	}
+}
+
+// pruneSummaries forgets summaries that weren't updated within the retention period, and
+// dropped messages older than it, like the history does. Failures past their backoff are
+// forgotten too. The caller must hold the write lock.
+func (b *Bot) pruneSummaries() {
+	for channelID, failed := range b.summaryFailed {
+		if time.Since(failed) >= summaryBackoff {
+			delete(b.summaryFailed, channelID)
+		}
+	}
+
+	cutoff := b.historyCutoff()
+	for channelID, summary := range b.summaries {
+		i := 0
+		for i < len(summary.Dropped) && summary.Dropped[i].Timestamp < cutoff {
+			i++
+		}
+		if i > 0 {
+			summary.Dropped = summary.Dropped[i:]
+			b.dirty.channels[channelID] = true
+		}
+
+		if summary.Updated >= cutoff {
+			continue
+		}
+
+		if len(summary.Dropped) == 0 {
+			delete(b.summaries, channelID)
+		} else {
+			summary.Content = ""
+		}
+		b.dirty.channels[channelID] = true
+	}
+}
+
+// handleSummary shows what the bot remembers about the channel beyond its latest messages.
+// Moderators can make it forget.
+func (b *Bot) handleSummary(c *CommandContext) {
+	if len(c.Args) > 0 && strings.ToLower(c.Args[0]) == "forget" {
+		b.forgetSummary(c)
+		return
+	}
+
+	if !b.config.History.Summary.Enabled {
+		c.Reply("🧠 I only remember the latest messages here, summaries are turned off.")
+		return
+	}
+
+	b.mutex.RLock()
+	summary := Summary{}
+	if s := b.summaries[c.ChannelID]; s != nil {
+		summary = *s
+	}
+	b.mutex.RUnlock()
+
+	if summary.Content == "" {
+		c.Reply("🧠 I don't remember anything here beyond the latest messages yet.")
+		return
+	}
+
+	// An embed, so usernames in the summary don't ping anyone
+	embed := &discordgo.MessageEmbed{
+		Title: "🧠 What I remember about this channel",
+		Description: fmt.Sprintf("%s\n\n_From %d earlier messages, updated <t:%d:R>_",
+			summary.Content, summary.Messages, summary.Updated),
+		Color: 0x9b59b6,
+	}
+	if err := deliverEmbed(c, embed); err != nil {
+		log.Errorf("Failed to send summary: %v", err)
+	}
+}
+
+func (b *Bot) forgetSummary(c *CommandContext) {
+	if c.GuildID != "" && !b.hasPermissions(c.Session, c.GuildID, c.ChannelID, c.Author.ID, c.Member, discordgo.PermissionManageMessages) {
+		c.Reply("❌ Only moderators can make me forget this channel.")
+		return
+	}
+
+	b.mutex.Lock()
+	// Keep where the summary got to, so the forgotten messages aren't summarized again
+	marker := &Summary{Updated: time.Now().Unix()}
+	if history := b.messageHistory[c.ChannelID]; len(history) > 0 {
+		last := history[len(history)-1]
+		marker.MessageID, marker.Timestamp = last.MessageID, last.Timestamp
+	}
+	b.summaries[c.ChannelID] = marker
+	b.dirty.channels[c.ChannelID] = true
+	b.mutex.Unlock()
+
+	if err := b.saveSettings(); err != nil {
+		log.Errorf("Failed to save settings: %v", err)
+	}
+
+	c.Reply("✅ Forgot what I remembered about this channel, what was said until now won't be summarized again.")
+}
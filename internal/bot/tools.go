@@ -0,0 +1,46 @@
+package bot
+
+import (
+	"github.com/charmbracelet/log"
+	"wherd.dev/chad/internal/openrouter"
+	"wherd.dev/chad/internal/tools"
+)
+
+func (b *Bot) registerTools() {
+	if b.search != nil {
+		b.tools.Register(tools.NewSearch(b.search, b.searchOptions(), b.pages, b.config.Search.Fetch.Pages))
+	}
+}
+
+// completeWithTools streams the request and runs the tools the model calls, feeding
+// the results back until it answers. Once the step limit is reached tool calls are
+// disabled so the model has to answer with what it has.
+func (b *Bot) completeWithTools(o *openrouter.OpenRouter, req *openrouter.Request, update func(content string)) (*openrouter.Response, error) {
+	if b.tools.Len() == 0 {
+		return streamCompletion(b.ctx, o, req, update)
+	}
+
+	req.Tools = b.tools.Definitions()
+	for step := 0; ; step++ {
+		if step >= b.config.OpenRouter.MaxToolSteps {
+			req.ToolChoice = "none"
+		}
+
+		response, err := streamCompletion(b.ctx, o, req, update)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(response.Choices) == 0 || len(response.Choices[0].Message.ToolCalls) == 0 || req.ToolChoice == "none" {
+			return response, nil
+		}
+
+		message := response.Choices[0].Message
+		req.Messages = append(req.Messages, &message)
+
+		for _, call := range message.ToolCalls {
+			log.Debugf("Calling tool %s with %s", call.Function.Name, call.Function.Arguments)
+			req.Messages = append(req.Messages, b.tools.Execute(b.ctx, &call))
+		}
+	}
+}
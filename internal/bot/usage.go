@@ -0,0 +1,232 @@
+package bot
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/charmbracelet/log"
+	"wherd.dev/chad/internal/config"
+	"wherd.dev/chad/internal/openrouter"
+)
+
+// Usage adds up the AI requests of a guild, or of a user or model in a guild, over a day or month.
+// It is keyed by "<period>|<guild>|<dimension>", where the period is a UTC day like "2026-10-17"
+// or month like "2026-10", the guild is "dm" for direct messages and the dimension is empty for
+// the whole guild, "user:<id>" or "model:<model>".
+type Usage struct {
+	Requests         int     `json:"requests"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	TotalTokens      int64   `json:"total_tokens"`
+	Cost             float64 `json:"cost"` // USD
+}
+
+const (
+	dayFormat   = "2006-01-02"
+	monthFormat = "2006-01"
+)
+
+// How long usage is kept, long enough to compare with the previous period.
+const (
+	dailyUsageRetention   = 62 * 24 * time.Hour
+	monthlyUsageRetention = 13
+)
+
+func usageKey(period string, guildID string, dimension string) string {
+	if guildID == "" {
+		guildID = "dm"
+	}
+	return period + "|" + guildID + "|" + dimension
+}
+
+// recordUsage adds what a response cost to the guild, the user and the model, for today and this month.
+func (b *Bot) recordUsage(guildID string, userID string, response *openrouter.Response) {
+	usage := response.Usage
+	if usage == nil {
+		log.Debugf("Generation %s by %s reported no usage", response.ID, response.Model)
+		return
+	}
+
+	log.Debugf("Generation %s by %s used %d prompt and %d completion tokens for $%.6f",
+		response.ID, response.Model, usage.PromptTokens, usage.CompletionTokens, usage.Cost)
+
+	dimensions := []string{"", "model:" + response.Model}
+	if userID != "" {
+		dimensions = append(dimensions, "user:"+userID)
+	}
+
+	now := time.Now().UTC()
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	for _, period := range []string{now.Format(dayFormat), now.Format(monthFormat)} {
+		for _, dimension := range dimensions {
+			key := usageKey(period, guildID, dimension)
+			total, ok := b.usage[key]
+			if !ok {
+				total = &Usage{}
+				b.usage[key] = total
+			}
+
+			total.Requests++
+			total.PromptTokens += int64(usage.PromptTokens)
+			total.CompletionTokens += int64(usage.CompletionTokens)
+			total.TotalTokens += int64(usage.TotalTokens)
+			total.Cost += usage.Cost
+		}
+	}
+
+	b.dirty.usage = true
+}
+
+// guildUsage returns the usage of the guild in the period, zero when there was none.
+func (b *Bot) guildUsage(period string, guildID string, dimension string) Usage {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	if usage, ok := b.usage[usageKey(period, guildID, dimension)]; ok {
+		return *usage
+	}
+	return Usage{}
+}
+
+// guildBudget returns the budget of the guild, falling back to the configured one.
+func (b *Bot) guildBudget(guildID string) config.GuildBudget {
+	if budget, ok := b.config.Budget.Guilds[guildID]; ok {
+		return budget
+	}
+	return config.GuildBudget{Daily: b.config.Budget.Daily, Monthly: b.config.Budget.Monthly}
+}
+
+// overBudget reports whether the guild spent its daily or monthly budget, with a message saying when it resets.
+func (b *Bot) overBudget(guildID string) (string, bool) {
+	if guildID == "" {
+		return "", false
+	}
+
+	budget := b.guildBudget(guildID)
+	now := time.Now().UTC()
+
+	if budget.Monthly > 0 && b.guildUsage(now.Format(monthFormat), guildID, "").Cost >= budget.Monthly {
+		reset := time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+		return fmt.Sprintf("💸 Sorry, this server used up its AI budget for the month. I'll be thinking again <t:%d:R>.", reset.Unix()), true
+	}
+
+	if budget.Daily > 0 && b.guildUsage(now.Format(dayFormat), guildID, "").Cost >= budget.Daily {
+		reset := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+		return fmt.Sprintf("💸 Sorry, this server used up its AI budget for today. I'll be thinking again <t:%d:R>.", reset.Unix()), true
+	}
+
+	return "", false
+}
+
+// pruneUsage forgets days and months past their retention. The caller must hold the write lock.
+func (b *Bot) pruneUsage() {
+	now := time.Now().UTC()
+	oldestDay := now.Add(-dailyUsageRetention).Format(dayFormat)
+	oldestMonth := now.AddDate(0, -monthlyUsageRetention, 0).Format(monthFormat)
+
+	for key := range b.usage {
+		period, _, _ := strings.Cut(key, "|")
+		if (len(period) == len(dayFormat) && period < oldestDay) || (len(period) == len(monthFormat) && period < oldestMonth) {
+			delete(b.usage, key)
+			b.dirty.usage = true
+		}
+	}
+}
+
+// topUsage returns the biggest spenders of a kind, like "user:", in the guild and period.
+func (b *Bot) topUsage(period string, guildID string, kind string, limit int) []string {
+	type spender struct {
+		name  string
+		usage *Usage
+	}
+
+	prefix := usageKey(period, guildID, kind)
+	spenders := []spender{}
+
+	b.mutex.RLock()
+	for key, usage := range b.usage {
+		if name, ok := strings.CutPrefix(key, prefix); ok {
+			spenders = append(spenders, spender{name, usage})
+		}
+	}
+
+	slices.SortFunc(spenders, func(x, y spender) int {
+		return cmp.Or(cmp.Compare(y.usage.Cost, x.usage.Cost), cmp.Compare(y.usage.TotalTokens, x.usage.TotalTokens))
+	})
+
+	lines := []string{}
+	for _, s := range spenders[:min(len(spenders), limit)] {
+		name := fmt.Sprintf("`%s`", s.name)
+		if kind == "user:" {
+			name = fmt.Sprintf("<@%s>", s.name)
+		}
+		lines = append(lines, fmt.Sprintf("%s %s", name, describeUsage(*s.usage)))
+	}
+	b.mutex.RUnlock()
+
+	return lines
+}
+
+func describeUsage(usage Usage) string {
+	requests := "requests"
+	if usage.Requests == 1 {
+		requests = "request"
+	}
+
+	return fmt.Sprintf("$%.4f, %d %s, %d tokens", usage.Cost, usage.Requests, requests, usage.TotalTokens)
+}
+
+// handleUsage reports what the AI cost in the server today and this month, against its budget.
+// Moderators also see who used it the most.
+func (b *Bot) handleUsage(c *CommandContext) {
+	now := time.Now().UTC()
+	day, month := now.Format(dayFormat), now.Format(monthFormat)
+	budget := b.guildBudget(c.GuildID)
+
+	title := "📊 AI usage in this server"
+	if c.GuildID == "" {
+		title = "📊 AI usage in direct messages"
+	}
+
+	report := &strings.Builder{}
+	for _, period := range []struct {
+		name   string
+		key    string
+		budget float64
+	}{
+		{"Today", day, budget.Daily},
+		{"This month", month, budget.Monthly},
+	} {
+		usage := b.guildUsage(period.key, c.GuildID, "")
+		fmt.Fprintf(report, "%s: %s", period.name, describeUsage(usage))
+		if period.budget > 0 && c.GuildID != "" {
+			fmt.Fprintf(report, " of a $%.2f budget (%.0f%%)", period.budget, usage.Cost/period.budget*100)
+		}
+		report.WriteString("\n")
+	}
+
+	if models := b.topUsage(month, c.GuildID, "model:", 5); len(models) > 0 {
+		report.WriteString("\n**Models this month**\n" + strings.Join(models, "\n") + "\n")
+	}
+
+	if c.GuildID != "" && b.hasPermissions(c.Session, c.GuildID, c.ChannelID, c.Author.ID, c.Member, discordgo.PermissionManageGuild) {
+		if users := b.topUsage(month, c.GuildID, "user:", 5); len(users) > 0 {
+			report.WriteString("\n**Top users this month**\n" + strings.Join(users, "\n") + "\n")
+		}
+	}
+
+	fmt.Fprintf(report, "\nYou this month: %s", describeUsage(b.guildUsage(month, c.GuildID, "user:"+c.Author.ID)))
+
+	// An embed, so listing users doesn't ping them
+	embed := &discordgo.MessageEmbed{Title: title, Description: report.String(), Color: 0x3498db}
+	if err := deliverEmbed(c, embed); err != nil {
+		log.Errorf("Failed to send usage: %v", err)
+	}
+}
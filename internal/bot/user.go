@@ -0,0 +1,74 @@
+package bot
+
+import (
+	"fmt"
+	"time"
+
+	// Time zones work the same on hosts without a zoneinfo database
+	_ "time/tzdata"
+
+	"github.com/charmbracelet/log"
+)
+
+type UserSettings struct {
+	TimeZone string `json:"time_zone,omitempty"`
+}
+
+// userSettingsFor returns the settings of the given user for modification, creating
+// them if needed. The caller must hold the write lock.
+func (b *Bot) userSettingsFor(userID string) *UserSettings {
+	b.dirty.users[userID] = true
+
+	settings, ok := b.userSettings[userID]
+	if !ok {
+		settings = &UserSettings{}
+		b.userSettings[userID] = settings
+	}
+
+	return settings
+}
+
+// userLocation returns the user's time zone, falling back to the configured one.
+func (b *Bot) userLocation(userID string) *time.Location {
+	b.mutex.RLock()
+	name := b.config.TimeZone
+	if settings, ok := b.userSettings[userID]; ok && settings.TimeZone != "" {
+		name = settings.TimeZone
+	}
+	b.mutex.RUnlock()
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		log.Errorf("Failed to load time zone %q: %v", name, err)
+		return time.UTC
+	}
+
+	return loc
+}
+
+// handleTimeZone shows or sets the time zone reminders are read in.
+func (b *Bot) handleTimeZone(c *CommandContext) {
+	if len(c.Args) < 2 {
+		loc := b.userLocation(c.Author.ID)
+		c.Reply(fmt.Sprintf("🕒 Your reminders use the %s time zone, it's %s there. Change it with `%sremind timezone <zone>`, like `Europe/Berlin`.",
+			loc, time.Now().In(loc).Format("15:04"), c.Prefix))
+		return
+	}
+
+	name := c.Args[1]
+	loc, err := time.LoadLocation(name)
+	if err != nil || name == "" || name == "Local" {
+		c.Reply(fmt.Sprintf("❌ Unknown time zone `%s`, use a name like `Europe/Berlin` or `America/New_York`.", name))
+		return
+	}
+
+	b.mutex.Lock()
+	b.userSettingsFor(c.Author.ID).TimeZone = loc.String()
+	b.mutex.Unlock()
+
+	if err := b.saveSettings(); err != nil {
+		log.Errorf("Failed to save settings: %v", err)
+	}
+
+	c.Reply(fmt.Sprintf("✅ Your time zone is now %s, it's %s there.", loc, time.Now().In(loc).Format("15:04")))
+}
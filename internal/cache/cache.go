@@ -0,0 +1,148 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// Cache keeps values for a while, and drops the least recently used ones once it is full.
+// It is safe for concurrent use.
+type Cache[V any] struct {
+	Clock func() time.Time // time.Now when nil
+
+	capacity int
+	ttl      time.Duration
+
+	mutex sync.Mutex
+	items map[string]*list.Element
+	order *list.List // Most recently used first
+	stats Stats
+}
+
+// Entry is a cached value, as it is saved to disk.
+type Entry[V any] struct {
+	Key     string `json:"key"`
+	Value   V      `json:"value"`
+	Expires int64  `json:"expires"` // Unix time
+}
+
+type Stats struct {
+	Hits      uint64 `json:"hits"`
+	Misses    uint64 `json:"misses"`
+	Evictions uint64 `json:"evictions"` // Dropped because the cache was full
+	Entries   int    `json:"entries"`
+}
+
+// HitRate returns the share of lookups that were hits, from 0 to 1.
+func (s Stats) HitRate() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+// New creates a cache for up to capacity values that expire after ttl.
+func New[V any](capacity int, ttl time.Duration) *Cache[V] {
+	return &Cache[V]{
+		capacity: capacity,
+		ttl:      ttl,
+		items:    map[string]*list.Element{},
+		order:    list.New(),
+	}
+}
+
+func (c *Cache[V]) now() time.Time {
+	if c.Clock != nil {
+		return c.Clock()
+	}
+	return time.Now()
+}
+
+// Get returns the value for the key, if it is cached and hasn't expired.
+func (c *Cache[V]) Get(key string) (V, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	element, ok := c.items[key]
+	if ok && element.Value.(*Entry[V]).Expires <= c.now().Unix() {
+		c.remove(element)
+		ok = false
+	}
+
+	if !ok {
+		c.stats.Misses++
+		var zero V
+		return zero, false
+	}
+
+	c.stats.Hits++
+	c.order.MoveToFront(element)
+	return element.Value.(*Entry[V]).Value, true
+}
+
+// Set caches the value for the key, evicting the least recently used value if the cache is full.
+func (c *Cache[V]) Set(key string, value V) {
+	c.set(&Entry[V]{Key: key, Value: value, Expires: c.now().Add(c.ttl).Unix()})
+}
+
+func (c *Cache[V]) set(entry *Entry[V]) {
+	if c.capacity <= 0 {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if element, ok := c.items[entry.Key]; ok {
+		c.remove(element)
+	}
+
+	c.items[entry.Key] = c.order.PushFront(entry)
+	for c.order.Len() > c.capacity {
+		c.remove(c.order.Back())
+		c.stats.Evictions++
+	}
+}
+
+// remove drops an element. The caller must hold the lock.
+func (c *Cache[V]) remove(element *list.Element) {
+	c.order.Remove(element)
+	delete(c.items, element.Value.(*Entry[V]).Key)
+}
+
+// Stats returns the hit and miss counts since the cache was created.
+func (c *Cache[V]) Stats() Stats {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	stats := c.stats
+	stats.Entries = c.order.Len()
+	return stats
+}
+
+// Entries returns the values that haven't expired, least recently used first, for saving them.
+func (c *Cache[V]) Entries() []Entry[V] {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	now := c.now().Unix()
+	entries := make([]Entry[V], 0, c.order.Len())
+	for element := c.order.Back(); element != nil; element = element.Prev() {
+		if entry := element.Value.(*Entry[V]); entry.Expires > now {
+			entries = append(entries, *entry)
+		}
+	}
+
+	return entries
+}
+
+// Restore adds saved values back in the order Entries returned them, skipping the ones that expired since.
+func (c *Cache[V]) Restore(entries []Entry[V]) {
+	now := c.now().Unix()
+	for _, entry := range entries {
+		if entry.Expires > now {
+			c.set(&entry)
+		}
+	}
+}
@@ -7,27 +7,141 @@ import (
 )
 
 type Config struct {
-	DiscordToken     string     `json:"discord_token"`
-	SearchApiKey     string     `json:"search_api"`
-	Prefix           string     `json:"prefix"`
-	AutoSaveInterval int        `json:"auto_save_interval"`
-	OpenRouter       OpenRouter `json:"open_router"`
-	RateLimit        RateLimit  `json:"rate_limit"`
+	DiscordToken      string        `json:"discord_token"`
+	SearchApiKey      string        `json:"search_api"` // Brave Search key, used when search has no providers
+	Prefix            string        `json:"prefix"`
+	AutoSaveInterval  int           `json:"auto_save_interval"`
+	AttachRepliesOver int           `json:"attach_replies_over"` // Attach replies longer than this as a file, 0 to always split them
+	TimeZone          string        `json:"time_zone"`           // Reminder time zone for users who did not set their own, UTC when empty
+	OpenRouter        OpenRouter    `json:"open_router"`
+	Search            Search        `json:"search"`
+	RateLimit         RateLimit     `json:"rate_limit"`
+	SlashCommands     SlashCommands `json:"slash_commands"`
+	History           History       `json:"history"`
+	Storage           Storage       `json:"storage"`
+	Cache             Cache         `json:"cache"`
+	Budget            Budget        `json:"budget"`
+}
+
+// Budget caps what the AI may cost in every server, in USD. Days and months are UTC.
+type Budget struct {
+	Daily   float64                `json:"daily"`   // 0 for no limit
+	Monthly float64                `json:"monthly"` // 0 for no limit
+	Guilds  map[string]GuildBudget `json:"guilds"`  // Budgets of single servers by ID, over the ones above
+}
+
+type GuildBudget struct {
+	Daily   float64 `json:"daily"`
+	Monthly float64 `json:"monthly"`
+}
+
+type Cache struct {
+	Path        string      `json:"path"`        // File the caches are kept in between restarts, memory only when empty
+	Search      CacheBucket `json:"search"`      // Search results
+	Completions CacheBucket `json:"completions"` // Answers to AI requests with temperature 0
+}
+
+type CacheBucket struct {
+	Size int   `json:"size"` // Entries kept, 0 to disable
+	TTL  int64 `json:"ttl"`  // Seconds
+}
+
+type Storage struct {
+	Driver string `json:"driver"` // "json" or "sqlite"
+	Path   string `json:"path"`   // Defaults to chad_memory.json or chad.db
+}
+
+type Search struct {
+	Providers  []SearchProvider `json:"providers"`   // Tried in order, the next one takes over when a search fails
+	Count      int              `json:"count"`       // Results per search
+	SafeSearch string           `json:"safe_search"` // "off", "moderate" or "strict"
+	Locale     string           `json:"locale"`      // Like "en-US", the provider's default when empty
+	Fetch      Fetch            `json:"fetch"`
+}
+
+// Fetch reads the pages of the top search results, so the model sees more than their snippets.
+type Fetch struct {
+	Pages    int   `json:"pages"`     // Top results to read, 0 to only use snippets
+	MaxBytes int64 `json:"max_bytes"` // Pages are cut off after this many bytes
+	Timeout  int64 `json:"timeout"`   // Seconds
+	MaxChars int   `json:"max_chars"` // Text of each page the model gets
+	CacheTTL int64 `json:"cache_ttl"` // Seconds pages and robots.txt files are kept
+}
+
+type SearchProvider struct {
+	Type    string            `json:"type"`    // "brave", "searxng" or "json"
+	URL     string            `json:"url"`     // Instance or endpoint URL, see websearch.JSON for placeholders
+	APIKey  string            `json:"api_key"` // Brave only
+	Headers map[string]string `json:"headers"` // JSON only
+	Results string            `json:"results"` // JSON only, dotted path to the list of results
+	Fields  SearchFields      `json:"fields"`  // JSON only
+}
+
+type SearchFields struct {
+	Title       string `json:"title"`
+	URL         string `json:"url"`
+	Description string `json:"description"`
+}
+
+type History struct {
+	Retention   int     `json:"retention"`    // Hours to keep channel messages, 0 to keep them forever
+	MaxMessages int     `json:"max_messages"` // Messages kept per channel, 0 for no limit
+	Summary     Summary `json:"summary"`
+}
+
+// Summary condenses the messages that no longer fit in the AI's context into a running summary
+// per channel, with the model of the summary task.
+type Summary struct {
+	Enabled   bool `json:"enabled"`
+	Batch     int  `json:"batch"`      // Messages summarized at once
+	MaxTokens int  `json:"max_tokens"` // Length of the summary
+}
+
+type SlashCommands struct {
+	Enabled bool     `json:"enabled"`
+	Guilds  []string `json:"guilds"` // Register in these guilds only, globally when empty
 }
 
 type RateLimit struct {
+	MaxRequests      int            `json:"max_requests"` // Per user
+	Window           int64          `json:"window"`
+	MuteTime         int64          `json:"mute_time"`         // Default for enforcement.timeout
+	Guild            Bucket         `json:"guild"`             // Shared by everyone in a guild, disabled when max_requests is 0
+	Channel          Bucket         `json:"channel"`           // Shared by everyone in a channel, disabled when max_requests is 0
+	Costs            map[string]int `json:"costs"`             // Command name or "mention" to its cost, 1 when unset
+	ExemptModerators bool           `json:"exempt_moderators"` // Moderator roles are never limited
+	Enforcement      Enforcement    `json:"enforcement"`
+}
+
+// Enforcement escalates against members who keep going over their rate limit. Every time
+// they do is a strike, the first strikes only earn a warning.
+type Enforcement struct {
+	CooldownAfter int   `json:"cooldown_after"` // Strikes before the bot ignores the member's commands for a while, 0 never
+	Cooldown      int64 `json:"cooldown"`       // Seconds
+	TimeoutAfter  int   `json:"timeout_after"`  // Strikes before the member gets a Discord timeout, 0 never
+	Timeout       int64 `json:"timeout"`        // Seconds
+	StrikeWindow  int64 `json:"strike_window"`  // Seconds a strike counts for
+}
+
+type Bucket struct {
 	MaxRequests int   `json:"max_requests"`
 	Window      int64 `json:"window"`
-	MuteTime    int64 `json:"mute_time"`
 }
 
 type OpenRouter struct {
-	Key                  string  `json:"key"`
-	SystemPrompt         string  `json:"system_prompt"`
-	Temperature          float64 `json:"temperature"`
-	MaxTokens            int     `json:"max_tokens"`
-	MaxMessagesInContext int     `json:"max_messages_in_context"`
-	Model                string  `json:"model"`
+	Key                  string            `json:"key"`
+	SystemPrompt         string            `json:"system_prompt"`
+	Temperature          *float64          `json:"temperature"` // Model default when unset
+	MaxTokens            int               `json:"max_tokens"`
+	MaxMessagesInContext int               `json:"max_messages_in_context"` // Cap on the channel's messages in a request, 0 for as many as fit
+	ContextTokens        int               `json:"context_tokens"`          // Tokens a request may fill with the channel's messages, the prompts, tools and answer
+	MaxMessageTokens     int               `json:"max_message_tokens"`      // Longer messages are cut down for the context
+	Model                string            `json:"model"`
+	Fallbacks            []string          `json:"fallbacks"`      // Models tried in order when the model fails
+	Tasks                map[string]string `json:"tasks"`          // Model for each task: chat, mention, ask, factcheck or summary
+	MaxToolSteps         int               `json:"max_tool_steps"` // Rounds of tool calls allowed before the model must answer
+	MaxRetries           int               `json:"max_retries"`    // Retries of requests that failed for a temporary reason
+	BaseURL              string            `json:"base_url"`       // API URL, OpenRouter's when empty
 }
 
 func LoadConfig(name string) (*Config, error) {
@@ -43,9 +157,58 @@ func LoadConfig(name string) (*Config, error) {
 			MaxRequests: 10,
 			Window:      60,
 			MuteTime:    60,
+			Costs: map[string]int{
+				"ask":       2,
+				"mention":   2,
+				"factcheck": 3,
+			},
+			ExemptModerators: true,
+			Enforcement: Enforcement{
+				CooldownAfter: 2,
+				Cooldown:      300,
+				TimeoutAfter:  4,
+				StrikeWindow:  3600,
+			},
+		},
+		OpenRouter: OpenRouter{
+			MaxToolSteps:     3,
+			ContextTokens:    16000,
+			MaxMessageTokens: 1000,
+			MaxRetries:       3,
+		},
+		Search: Search{
+			Count:      7,
+			SafeSearch: "strict",
+			Fetch: Fetch{
+				Pages:    3,
+				MaxBytes: 1 << 20,
+				Timeout:  10,
+				MaxChars: 2000,
+				CacheTTL: 3600,
+			},
+		},
+		SlashCommands: SlashCommands{
+			Enabled: true,
+		},
+		History: History{
+			Retention:   24 * 7,
+			MaxMessages: 50,
+			Summary: Summary{
+				Batch:     10,
+				MaxTokens: 400,
+			},
+		},
+		Cache: Cache{
+			Search:      CacheBucket{Size: 500, TTL: 1800},
+			Completions: CacheBucket{Size: 200, TTL: 86400},
 		},
 	}
 
 	json.NewDecoder(bytes.NewBuffer(b)).Decode(config)
+
+	if config.RateLimit.Enforcement.Timeout == 0 {
+		config.RateLimit.Enforcement.Timeout = config.RateLimit.MuteTime
+	}
+
 	return config, nil
 }
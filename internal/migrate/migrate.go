@@ -0,0 +1,134 @@
+package migrate
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Document is a data file decoded without a schema, so migrations can reshape it freely.
+type Document map[string]any
+
+// Step upgrades a document from one version to the next.
+type Step struct {
+	From    string
+	To      string
+	Migrate func(doc Document) error
+}
+
+var steps = []Step{}
+
+// Register adds a migration step. Steps must be registered in version order.
+func Register(from string, to string, migrate func(doc Document) error) {
+	if len(steps) > 0 && steps[len(steps)-1].To != from {
+		panic(fmt.Sprintf("migration from %s does not follow %s", from, steps[len(steps)-1].To))
+	}
+
+	steps = append(steps, Step{From: from, To: to, Migrate: migrate})
+}
+
+// Latest returns the version produced by the last registered step.
+func Latest() string {
+	return steps[len(steps)-1].To
+}
+
+// Version returns the version of the document, "1.0" files may not have one.
+func (doc Document) Version() string {
+	if version, ok := doc["version"].(string); ok && version != "" {
+		return version
+	}
+
+	return "1.0"
+}
+
+// Pending returns the steps needed to bring the document to the latest version.
+func Pending(doc Document) ([]Step, error) {
+	version := doc.Version()
+	if version == Latest() {
+		return nil, nil
+	}
+
+	for i, step := range steps {
+		if step.From == version {
+			return steps[i:], nil
+		}
+	}
+
+	return nil, fmt.Errorf("unknown data version %s, this build supports up to %s", version, Latest())
+}
+
+// Apply migrates the document to the latest version in place and returns the steps applied.
+func Apply(doc Document) ([]Step, error) {
+	pending, err := Pending(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, step := range pending {
+		if err := step.Migrate(doc); err != nil {
+			return nil, fmt.Errorf("migration from %s to %s failed: %w", step.From, step.To, err)
+		}
+		doc["version"] = step.To
+	}
+
+	return pending, nil
+}
+
+// ReadFile decodes a data file.
+func ReadFile(path string) (Document, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := Document{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%s is not a valid data file: %w", path, err)
+	}
+
+	return doc, nil
+}
+
+// MigrateFile brings the data file to the latest version. The original file is kept
+// next to it as a backup, named after its version, and the returned path points to it.
+// Files already at the latest version are left untouched.
+func MigrateFile(path string) (applied []Step, backup string, err error) {
+	doc, err := ReadFile(path)
+	if err != nil {
+		return nil, "", err
+	}
+
+	original, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", err
+	}
+
+	from := doc.Version()
+	applied, err = Apply(doc)
+	if err != nil || len(applied) == 0 {
+		return nil, "", err
+	}
+
+	backup = fmt.Sprintf("%s.v%s-%s.bak", path, from, time.Now().Format("20060102150405"))
+	if err := os.WriteFile(backup, original, 0644); err != nil {
+		return nil, "", fmt.Errorf("could not write backup: %w", err)
+	}
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, "", err
+	}
+
+	tempFile := path + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0644); err != nil {
+		return nil, "", err
+	}
+
+	if err := os.Rename(tempFile, path); err != nil {
+		os.Remove(tempFile)
+		return nil, "", err
+	}
+
+	return applied, backup, nil
+}
@@ -0,0 +1,188 @@
+package migrate
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+// withSteps replaces the registered steps for the test.
+func withSteps(t *testing.T, replacement []Step) {
+	registered := steps
+	steps = replacement
+	t.Cleanup(func() {
+		steps = registered
+	})
+}
+
+func TestVersion(t *testing.T) {
+	tests := []struct {
+		doc  Document
+		want string
+	}{
+		{Document{}, "1.0"},
+		{Document{"version": ""}, "1.0"},
+		{Document{"version": 2}, "1.0"},
+		{Document{"version": "1.1"}, "1.1"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.doc.Version(); got != tt.want {
+			t.Errorf("Version of %v = %q, want %q", tt.doc, got, tt.want)
+		}
+	}
+}
+
+func TestPending(t *testing.T) {
+	tests := []struct {
+		version string
+		want    []string
+		fails   bool
+	}{
+		{"1.0", []string{"1.1", "1.2"}, false},
+		{"1.1", []string{"1.2"}, false},
+		{"1.2", nil, false},
+		{"0.9", nil, true},
+		{"2.0", nil, true},
+	}
+
+	for _, tt := range tests {
+		pending, err := Pending(Document{"version": tt.version})
+		if tt.fails != (err != nil) {
+			t.Errorf("Pending(%s) error = %v, want failure %v", tt.version, err, tt.fails)
+		}
+
+		got := []string(nil)
+		for _, step := range pending {
+			got = append(got, step.To)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("Pending(%s) goes to %q, want %q", tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestApplyOrder(t *testing.T) {
+	order := []string{}
+	step := func(from string, to string) Step {
+		return Step{From: from, To: to, Migrate: func(doc Document) error {
+			if doc.Version() != from {
+				t.Errorf("step to %s ran on a %s document", to, doc.Version())
+			}
+			order = append(order, to)
+			return nil
+		}}
+	}
+	withSteps(t, []Step{step("1.0", "1.1"), step("1.1", "1.2"), step("1.2", "2.0")})
+
+	doc := Document{"version": "1.1"}
+	applied, err := Apply(doc)
+	if err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+
+	if want := []string{"1.2", "2.0"}; !slices.Equal(order, want) {
+		t.Errorf("ran the steps to %q, want %q", order, want)
+	}
+	if len(applied) != 2 || doc.Version() != "2.0" {
+		t.Errorf("applied %d steps up to %s, want 2 up to 2.0", len(applied), doc.Version())
+	}
+}
+
+func TestApply(t *testing.T) {
+	doc := Document{
+		"reminders":   []any{},
+		"rate_limits": map[string]any{"123": []any{1.0}, "456": []any{2.0, 3.0}},
+	}
+
+	if _, err := Apply(doc); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+
+	want := Document{
+		"version":     "1.2",
+		"reminders":   []any{},
+		"rate_limits": map[string]any{"user:123": []any{1.0}, "user:456": []any{2.0, 3.0}},
+	}
+	if !reflect.DeepEqual(doc, want) {
+		t.Errorf("migrated document = %v, want %v", doc, want)
+	}
+}
+
+func TestMigrateFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "chad_memory.json")
+	original := []byte(`{"version":"1.1","rate_limits":{"123":[1]}}`)
+	if err := os.WriteFile(path, original, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	applied, backup, err := MigrateFile(path)
+	if err != nil {
+		t.Fatalf("MigrateFile failed: %v", err)
+	}
+	if len(applied) != 1 {
+		t.Errorf("applied %d steps, want 1", len(applied))
+	}
+
+	if saved, err := os.ReadFile(backup); err != nil || string(saved) != string(original) {
+		t.Errorf("backup %s = %s, %v, want the original file", backup, saved, err)
+	}
+
+	doc, err := ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read the migrated file: %v", err)
+	}
+	if _, ok := doc["rate_limits"].(map[string]any)["user:123"]; !ok || doc.Version() != Latest() {
+		t.Errorf("migrated file = %v", doc)
+	}
+
+	migrated, _ := os.ReadFile(path)
+	applied, backup, err = MigrateFile(path)
+	if err != nil || len(applied) != 0 || backup != "" {
+		t.Errorf("migrating again = %d steps, backup %q, %v, want nothing to do", len(applied), backup, err)
+	}
+	if again, _ := os.ReadFile(path); string(again) != string(migrated) {
+		t.Errorf("migrating again changed the file")
+	}
+
+	backups, _ := filepath.Glob(path + ".*.bak")
+	if len(backups) != 1 {
+		t.Errorf("found backups %q, want one", backups)
+	}
+}
+
+func TestMigrateFileInvalid(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := map[string]string{
+		"newer.json":   `{"version":"9.0"}`,
+		"invalid.json": `{"version":`,
+	}
+
+	for name, content := range tests {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, _, err := MigrateFile(path); err == nil {
+			t.Errorf("MigrateFile(%s) succeeded", name)
+		}
+		if saved, _ := os.ReadFile(path); string(saved) != content {
+			t.Errorf("MigrateFile(%s) changed the file it couldn't migrate", name)
+		}
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != len(tests) {
+		t.Errorf("found %d files, want no backups of files that weren't migrated", len(entries))
+	}
+}
+
+func TestMigrateFileMissing(t *testing.T) {
+	if _, _, err := MigrateFile(filepath.Join(t.TempDir(), "missing.json")); !os.IsNotExist(err) {
+		t.Errorf("err = %v, want the file not to exist", err)
+	}
+}
@@ -0,0 +1,119 @@
+package migrate
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// SQLStep upgrades a database from one schema version to the next.
+type SQLStep struct {
+	From    string
+	To      string
+	Migrate func(tx *sql.Tx) error
+}
+
+var sqlSteps = []SQLStep{}
+
+// RegisterSQL adds a database migration step. Steps must be registered in version order.
+func RegisterSQL(from string, to string, migrate func(tx *sql.Tx) error) {
+	if len(sqlSteps) > 0 && sqlSteps[len(sqlSteps)-1].To != from {
+		panic(fmt.Sprintf("database migration from %s does not follow %s", from, sqlSteps[len(sqlSteps)-1].To))
+	}
+
+	sqlSteps = append(sqlSteps, SQLStep{From: from, To: to, Migrate: migrate})
+}
+
+// LatestSQL returns the schema version produced by the last registered database step.
+func LatestSQL() string {
+	return sqlSteps[len(sqlSteps)-1].To
+}
+
+// SQLVersion returns the schema version of a database, kept in its meta table. It is empty
+// for a new database, and databases from before schema versions are "1.1".
+func SQLVersion(db *sql.DB) (string, error) {
+	var tables int
+	if err := db.QueryRow("SELECT count(*) FROM sqlite_master WHERE type = 'table' AND name = 'meta'").Scan(&tables); err != nil {
+		return "", err
+	}
+	if tables == 0 {
+		return "", nil
+	}
+
+	var version string
+	err := db.QueryRow("SELECT value FROM meta WHERE key = 'schema_version'").Scan(&version)
+	if err == sql.ErrNoRows {
+		return "1.1", nil
+	}
+
+	return version, err
+}
+
+// SetSQLVersion records the schema version of a database with a meta table.
+func SetSQLVersion(db interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}, version string) error {
+	_, err := db.Exec("INSERT OR REPLACE INTO meta (key, value) VALUES ('schema_version', ?)", version)
+	return err
+}
+
+// PendingSQL returns the steps needed to bring a database at the version to the latest one.
+func PendingSQL(version string) ([]SQLStep, error) {
+	if version == "" || version == LatestSQL() {
+		return nil, nil
+	}
+
+	for i, step := range sqlSteps {
+		if step.From == version {
+			return sqlSteps[i:], nil
+		}
+	}
+
+	return nil, fmt.Errorf("unknown database version %s, this build supports up to %s", version, LatestSQL())
+}
+
+// MigrateSQL brings the database at path to the latest schema version and returns the
+// steps applied. A copy of the database is written next to it first, named after its
+// version like the backups of MigrateFile, and the returned path points to it. Every step
+// runs in its own transaction, which also records the version it reached.
+func MigrateSQL(db *sql.DB, path string) (applied []SQLStep, backup string, err error) {
+	version, err := SQLVersion(db)
+	if err != nil {
+		return nil, "", err
+	}
+
+	pending, err := PendingSQL(version)
+	if err != nil || len(pending) == 0 {
+		return nil, "", err
+	}
+
+	backup = fmt.Sprintf("%s.v%s-%s.bak", path, version, time.Now().Format("20060102150405"))
+	if _, err := db.Exec("VACUUM INTO ?", backup); err != nil {
+		return nil, "", fmt.Errorf("could not write backup: %w", err)
+	}
+
+	for _, step := range pending {
+		tx, err := db.Begin()
+		if err != nil {
+			return applied, backup, err
+		}
+
+		if err := step.Migrate(tx); err != nil {
+			tx.Rollback()
+			return applied, backup, fmt.Errorf("database migration from %s to %s failed: %w", step.From, step.To, err)
+		}
+
+		if err := SetSQLVersion(tx, step.To); err != nil {
+			tx.Rollback()
+			return applied, backup, err
+		}
+
+		if err := tx.Commit(); err != nil {
+			return applied, backup, err
+		}
+
+		applied = append(applied, step)
+	}
+
+	return applied, backup, nil
+}
@@ -0,0 +1,165 @@
+package migrate
+
+import (
+	"database/sql"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+// openTestDB opens a database in the test's directory, created with the queries.
+func openTestDB(t *testing.T, queries ...string) (*sql.DB, string) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "chad.db")
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	for _, query := range queries {
+		if _, err := db.Exec(query); err != nil {
+			t.Fatalf("%s: %v", query, err)
+		}
+	}
+
+	return db, path
+}
+
+// rateLimitKeys returns the keys of the rate limits in the column.
+func rateLimitKeys(t *testing.T, db *sql.DB, column string) []string {
+	t.Helper()
+
+	rows, err := db.Query("SELECT " + column + " FROM rate_limits ORDER BY 1")
+	if err != nil {
+		t.Fatalf("failed to read rate limits: %v", err)
+	}
+	defer rows.Close()
+
+	keys := []string{}
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, key)
+	}
+
+	return keys
+}
+
+// oldDatabase is a database from before schema versions.
+var oldDatabase = []string{
+	"CREATE TABLE meta (key TEXT PRIMARY KEY, value TEXT NOT NULL)",
+	"CREATE TABLE rate_limits (user_id TEXT PRIMARY KEY, timestamps TEXT NOT NULL)",
+	"INSERT INTO rate_limits VALUES ('123', '[1]'), ('456', '[2]')",
+}
+
+func TestSQLVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		queries []string
+		want    string
+	}{
+		{"new database", nil, ""},
+		{"before schema versions", oldDatabase, "1.1"},
+		{"versioned", []string{
+			"CREATE TABLE meta (key TEXT PRIMARY KEY, value TEXT NOT NULL)",
+			"INSERT INTO meta VALUES ('schema_version', '1.2')",
+		}, "1.2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, _ := openTestDB(t, tt.queries...)
+			if got, err := SQLVersion(db); err != nil || got != tt.want {
+				t.Errorf("SQLVersion = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestPendingSQL(t *testing.T) {
+	tests := []struct {
+		version string
+		want    []string
+		fails   bool
+	}{
+		{"", nil, false},
+		{"1.1", []string{"1.2"}, false},
+		{"1.2", nil, false},
+		{"1.0", nil, true},
+		{"9.0", nil, true},
+	}
+
+	for _, tt := range tests {
+		pending, err := PendingSQL(tt.version)
+		if tt.fails != (err != nil) {
+			t.Errorf("PendingSQL(%q) error = %v, want failure %v", tt.version, err, tt.fails)
+		}
+
+		got := []string(nil)
+		for _, step := range pending {
+			got = append(got, step.To)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("PendingSQL(%q) goes to %q, want %q", tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestMigrateSQL(t *testing.T) {
+	db, path := openTestDB(t, oldDatabase...)
+
+	applied, backup, err := MigrateSQL(db, path)
+	if err != nil {
+		t.Fatalf("MigrateSQL failed: %v", err)
+	}
+	if len(applied) != 1 || applied[0].To != "1.2" {
+		t.Errorf("applied %v, want the step to 1.2", applied)
+	}
+
+	if want := []string{"user:123", "user:456"}; !slices.Equal(rateLimitKeys(t, db, "key"), want) {
+		t.Errorf("rate limit keys = %q, want %q", rateLimitKeys(t, db, "key"), want)
+	}
+	if version, _ := SQLVersion(db); version != "1.2" {
+		t.Errorf("version after migrating = %q, want 1.2", version)
+	}
+
+	// The backup is the database as it was
+	old, err := sql.Open("sqlite", backup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer old.Close()
+	if want := []string{"123", "456"}; !slices.Equal(rateLimitKeys(t, old, "user_id"), want) {
+		t.Errorf("rate limit keys in the backup = %q, want %q", rateLimitKeys(t, old, "user_id"), want)
+	}
+	if version, _ := SQLVersion(old); version != "1.1" {
+		t.Errorf("version of the backup = %q, want 1.1", version)
+	}
+
+	applied, backup, err = MigrateSQL(db, path)
+	if err != nil || len(applied) != 0 || backup != "" {
+		t.Errorf("migrating again = %d steps, backup %q, %v, want nothing to do", len(applied), backup, err)
+	}
+}
+
+func TestMigrateSQLFailure(t *testing.T) {
+	// Without a rate_limits table the step fails, and nothing of it is kept
+	db, path := openTestDB(t, "CREATE TABLE meta (key TEXT PRIMARY KEY, value TEXT NOT NULL)")
+
+	applied, backup, err := MigrateSQL(db, path)
+	if err == nil {
+		t.Fatalf("MigrateSQL succeeded")
+	}
+	if len(applied) != 0 || backup == "" {
+		t.Errorf("applied %d steps with backup %q, want none and a backup", len(applied), backup)
+	}
+	if version, _ := SQLVersion(db); version != "1.1" {
+		t.Errorf("version after a failed migration = %q, want 1.1", version)
+	}
+}
@@ -0,0 +1,37 @@
+package migrate
+
+import "database/sql"
+
+func init() {
+	// 1.1 persists channel history, guild settings and rate limits. All of them are
+	// optional, so 1.0 files only need their version bumped.
+	Register("1.0", "1.1", func(doc Document) error {
+		return nil
+	})
+
+	// 1.2 keys rate limits by scope, 1.1 only had per-user limits keyed by user ID
+	Register("1.1", "1.2", func(doc Document) error {
+		rateLimits, ok := doc["rate_limits"].(map[string]any)
+		if !ok {
+			return nil
+		}
+
+		scoped := map[string]any{}
+		for userID, usage := range rateLimits {
+			scoped["user:"+userID] = usage
+		}
+		doc["rate_limits"] = scoped
+		return nil
+	})
+
+	// Databases follow the same versions, 1.1 is the first one with an SQLite store. Its
+	// rate limits were per user in a user_id column, 1.2 keys them by scope.
+	RegisterSQL("1.1", "1.2", func(tx *sql.Tx) error {
+		if _, err := tx.Exec("UPDATE rate_limits SET user_id = 'user:' || user_id WHERE user_id NOT LIKE '%:%'"); err != nil {
+			return err
+		}
+
+		_, err := tx.Exec("ALTER TABLE rate_limits RENAME COLUMN user_id TO key")
+		return err
+	})
+}
@@ -0,0 +1,75 @@
+package openrouter
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Errors an APIError matches with errors.Is, by its code.
+var (
+	ErrBadRequest          = errors.New("bad request")                         // 400, like an unsupported parameter
+	ErrUnauthorized        = errors.New("invalid API key")                     // 401
+	ErrInsufficientCredits = errors.New("insufficient credits")                // 402
+	ErrModerated           = errors.New("input flagged by moderation")         // 403
+	ErrTimeout             = errors.New("request timed out")                   // 408
+	ErrRateLimited         = errors.New("rate limited")                        // 429
+	ErrProviderDown        = errors.New("model provider is down")              // 502
+	ErrUnavailable         = errors.New("no provider available for the model") // 503
+)
+
+var errorCodes = map[int]error{
+	http.StatusBadRequest:         ErrBadRequest,
+	http.StatusUnauthorized:       ErrUnauthorized,
+	http.StatusPaymentRequired:    ErrInsufficientCredits,
+	http.StatusForbidden:          ErrModerated,
+	http.StatusRequestTimeout:     ErrTimeout,
+	http.StatusTooManyRequests:    ErrRateLimited,
+	http.StatusBadGateway:         ErrProviderDown,
+	http.StatusServiceUnavailable: ErrUnavailable,
+}
+
+// APIError is an error OpenRouter answered with, either as the response status or in the body.
+type APIError struct {
+	StatusCode int            // HTTP status, 200 for errors in the body of a response
+	Code       int            // OpenRouter's error code, usually the same as the status
+	Message    string         // As OpenRouter wrote it
+	Metadata   map[string]any // Details like the provider's raw error
+	RetryAfter time.Duration  // From the Retry-After header, 0 when it wasn't sent
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("API returned status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("API error %d: %s", e.code(), e.Message)
+}
+
+// Is matches the errors above by code.
+func (e *APIError) Is(target error) bool {
+	err, ok := errorCodes[e.code()]
+	return ok && err == target
+}
+
+// Temporary reports whether the same request might succeed later.
+func (e *APIError) Temporary() bool {
+	switch code := e.code(); code {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests:
+		return true
+	default:
+		return code >= 500
+	}
+}
+
+func (e *APIError) code() int {
+	if e.Code != 0 {
+		return e.Code
+	}
+	return e.StatusCode
+}
+
+// apiError converts the error of a response body.
+func (e *Error) apiError(statusCode int) *APIError {
+	return &APIError{StatusCode: statusCode, Code: e.Code, Message: e.Message, Metadata: e.Metadata}
+}
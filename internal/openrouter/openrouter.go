@@ -3,29 +3,86 @@ package openrouter
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"math/rand/v2"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
+
+	"wherd.dev/chad/internal/cache"
 )
 
 type OpenRouter struct {
-	Key                  string  `json:"key"`
-	SystemPrompt         string  `json:"system_prompt"`
-	Temperature          float64 `json:"temperature"`
-	MaxTokens            int     `json:"max_tokens"`
-	MaxMessagesInContext int     `json:"max_messages_in_context"`
-	Model                string  `json:"model"`
+	Key                  string   `json:"key"`
+	SystemPrompt         string   `json:"system_prompt"`
+	Temperature          *float64 `json:"temperature"`
+	MaxTokens            int      `json:"max_tokens"`
+	MaxMessagesInContext int      `json:"max_messages_in_context"`
+	Model                string   `json:"model"`
+
+	Cache      *cache.Cache[*Response]  `json:"-"` // Responses to deterministic requests, see Cacheable
+	Client     *http.Client             `json:"-"` // http.DefaultClient when nil
+	BaseURL    string                   `json:"-"` // defaultBaseURL when empty
+	MaxRetries int                      `json:"-"` // Retries of requests that failed for a temporary reason
+	Fallbacks  []string                 `json:"-"` // Models tried in order when the model fails
+	OnResponse func(response *Response) `json:"-"` // Called for every answer from the API, not for cached ones
+
+	ContextTokens    int       `json:"-"` // Tokens FitContext may fill, including the answer, no limit when 0
+	MaxMessageTokens int       `json:"-"` // Tokens FitContext cuts longer messages down to, no limit when 0
+	Tokenizer        Tokenizer `json:"-"` // Heuristic when nil
 }
 
 type Request struct {
-	Messages       []*Message `json:"messages,omitempty"`
-	Prompt         string     `json:"prompt,omitempty"`
-	Model          string     `json:"model,omitempty"`           // See "Supported Models" section
-	ResponseFormat string     `json:"response_format,omitempty"` // response_format?: { type: 'json_object' };
-	MaxTokens      int        `json:"max_tokens,omitempty"`      // Range: [1, context_length)
-	Temperature    float64    `json:"temperature,omitempty"`     // Range: [0, 2]
-	Tools          []Tool     `json:"tools,omitempty"`           // tools?: Tool[];
+	Messages       []*Message      `json:"messages,omitempty"`
+	Prompt         string          `json:"prompt,omitempty"`
+	Model          string          `json:"model,omitempty"` // See "Supported Models" section
+	ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
+	MaxTokens      int             `json:"max_tokens,omitempty"`  // Range: [1, context_length)
+	Temperature    *float64        `json:"temperature,omitempty"` // Range: [0, 2]
+	Tools          []Tool          `json:"tools,omitempty"`       // tools?: Tool[];
+	ToolChoice     string          `json:"tool_choice,omitempty"` // 'none' | 'auto' | 'required'
+	Stream         bool            `json:"stream,omitempty"`
+	Usage          *UsageOptions   `json:"usage,omitempty"`
+}
+
+// UsageOptions asks for the cost of a request in its usage.
+type UsageOptions struct {
+	Include bool `json:"include"`
+}
+
+// Usage is what a request cost.
+type Usage struct {
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	TotalTokens      int     `json:"total_tokens"`
+	Cost             float64 `json:"cost"` // In credits, which are USD
+}
+
+// ResponseFormat makes the model answer with JSON, matching a schema for json_schema.
+type ResponseFormat struct {
+	Type       string      `json:"type"` // 'json_object' | 'json_schema'
+	JSONSchema *JSONSchema `json:"json_schema,omitempty"`
+}
+
+type JSONSchema struct {
+	Name   string  `json:"name"`
+	Strict bool    `json:"strict,omitempty"`
+	Schema *Schema `json:"schema"`
+}
+
+// JSONResponse asks for an answer matching the schema.
+func JSONResponse(name string, schema *Schema) *ResponseFormat {
+	return &ResponseFormat{
+		Type:       "json_schema",
+		JSONSchema: &JSONSchema{Name: name, Schema: schema},
+	}
 }
 
 type Message struct {
@@ -33,7 +90,7 @@ type Message struct {
 	Name       string     `json:"name,omitempty"`
 	Content    string     `json:"content"`
 	ToolCallID string     `json:"tool_call_id,omitempty"`
-	TollCalls  []ToolCall `json:"tool_calls,omitempty"`
+	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`
 }
 
 type ToolCall struct {
@@ -49,40 +106,35 @@ type FunctionCall struct {
 
 type Tool struct {
 	Type     string   `json:"type"`
-	Fucntion Function `json:"function"`
+	Function Function `json:"function"`
 }
 
 type Function struct {
-	Name        string                `json:"name"`
-	Description string                `json:"description"`
-	Parameters  map[string]Parameters `json:"parameters"`
-}
-
-type Parameters struct {
-	Type       string          `json:"type"`
-	Properties map[string]Type `json:"properties"`
-	Required   []string        `json:"required,omitempty"`
-}
-
-type Type struct {
-	Type string `json:"type"`
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Parameters  *Schema `json:"parameters"`
 }
 
 type Response struct {
+	ID      string    `json:"id,omitempty"`    // Generation ID
+	Model   string    `json:"model,omitempty"` // The model that answered
+	Usage   *Usage    `json:"usage,omitempty"`
 	Choices []*Choice `json:"choices,omitempty"`
 	Error   *Error    `json:"error,omitempty"`
 }
 
 type Error struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
+	Code     int            `json:"code"`
+	Message  string         `json:"message"`
+	Metadata map[string]any `json:"metadata,omitempty"`
 }
 
 type Choice struct {
-	Message Message `json:"message"`
+	Message      Message `json:"message"`
+	FinishReason string  `json:"finish_reason,omitempty"`
 }
 
-func New(key string, systemPrompt string, temperature float64, maxTokens int, maxMessagesInContext int, model string) *OpenRouter {
+func New(key string, systemPrompt string, temperature *float64, maxTokens int, maxMessagesInContext int, model string) *OpenRouter {
 	return &OpenRouter{
 		Key:                  key,
 		SystemPrompt:         systemPrompt,
@@ -90,10 +142,122 @@ func New(key string, systemPrompt string, temperature float64, maxTokens int, ma
 		MaxTokens:            maxTokens,
 		MaxMessagesInContext: maxMessagesInContext,
 		Model:                model,
+		MaxRetries:           3,
+	}
+}
+
+const defaultBaseURL = "https://openrouter.ai/api/v1"
+
+// Send sends the request and waits for the whole response.
+func (o *OpenRouter) Send(ctx context.Context, r *Request) (*Response, error) {
+	key, cacheable := o.cacheKey(r)
+	if cacheable {
+		if response, ok := o.Cache.Get(key); ok {
+			return response, nil
+		}
 	}
+
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
+
+	var response *Response
+	err := o.fallback(ctx, r, func(retries int) error {
+		resp, err := o.post(ctx, r, retries)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		response = &Response{}
+		if err := json.NewDecoder(resp.Body).Decode(response); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+
+		if response.Error != nil {
+			return response.Error.apiError(resp.StatusCode)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if o.OnResponse != nil {
+		o.OnResponse(response)
+	}
+
+	if cacheable {
+		o.Cache.Set(key, response)
+	}
+
+	return response, nil
 }
 
-func (o *OpenRouter) Send(r *Request) (*Response, error) {
+// Cacheable reports whether the request always gets the same answer, because its temperature is 0.
+func (r *Request) Cacheable() bool {
+	return r.Temperature != nil && *r.Temperature == 0
+}
+
+// cacheKey returns the key a request's response is cached under, if it is cached at all.
+func (o *OpenRouter) cacheKey(r *Request) (string, bool) {
+	if o.Cache == nil || !r.Cacheable() {
+		return "", false
+	}
+
+	stream := r.Stream
+	r.Stream = false
+	data, err := json.Marshal(r)
+	r.Stream = stream
+	if err != nil {
+		return "", false
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), true
+}
+
+// fallback sends the request with its model, then with each fallback model while they fail.
+// Only the last model is retried, the others give way to the next one right away.
+func (o *OpenRouter) fallback(ctx context.Context, r *Request, send func(retries int) error) error {
+	model := r.Model
+	defer func() { r.Model = model }()
+
+	models := []string{model}
+	for _, fallback := range o.Fallbacks {
+		if !slices.Contains(models, fallback) {
+			models = append(models, fallback)
+		}
+	}
+
+	var err error
+	for i, model := range models {
+		r.Model = model
+
+		retries := 0
+		if i == len(models)-1 {
+			retries = o.MaxRetries
+		}
+
+		err = send(retries)
+		if err == nil || !fallsBack(ctx, err) {
+			return err
+		}
+	}
+
+	return err
+}
+
+// fallsBack reports whether another model might succeed where one failed. Problems with the
+// account affect every model, and streams can't start over once they were shown.
+func fallsBack(ctx context.Context, err error) bool {
+	var partial *streamError
+	return ctx.Err() == nil && !errors.Is(err, ErrUnauthorized) && !errors.Is(err, ErrInsufficientCredits) && !errors.As(err, &partial)
+}
+
+// post sends the request, retrying it with backoff while it fails for a temporary reason.
+// The caller must close the body of the response.
+func (o *OpenRouter) post(ctx context.Context, r *Request, retries int) (*http.Response, error) {
 	if len(r.Messages) <= 1 && r.Prompt == "" {
 		return nil, fmt.Errorf("empty prompt provided")
 	}
@@ -103,10 +267,33 @@ func (o *OpenRouter) Send(r *Request) (*Response, error) {
 		return nil, err
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
+	for attempt := 0; ; attempt++ {
+		resp, err := o.do(ctx, jsonData)
+		if err == nil {
+			return resp, nil
+		}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", "https://openrouter.ai/api/v1/chat/completions", bytes.NewBuffer(jsonData))
+		wait, retry := retryDelay(ctx, err, attempt, retries)
+		if !retry {
+			return nil, err
+		}
+
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return nil, err
+		}
+	}
+}
+
+// do sends the request once, turning error responses into an APIError.
+func (o *OpenRouter) do(ctx context.Context, body []byte) (*http.Response, error) {
+	baseURL := o.BaseURL
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", strings.TrimSuffix(baseURL, "/")+"/chat/completions", bytes.NewReader(body))
 	if err != nil {
 		return nil, err
 	}
@@ -115,26 +302,79 @@ func (o *OpenRouter) Send(r *Request) (*Response, error) {
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("X-Title", "Chad Discord Bot")
 
-	resp, err := http.DefaultClient.Do(req)
+	client := o.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
 	if err != nil {
 		return nil, err
 	}
+
+	if resp.StatusCode == http.StatusOK {
+		return resp, nil
+	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API returned status %d", resp.StatusCode)
+	apiErr := &APIError{StatusCode: resp.StatusCode, RetryAfter: retryAfter(resp.Header.Get("Retry-After"))}
+
+	var response Response
+	if data, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024)); err == nil {
+		if json.Unmarshal(data, &response) == nil && response.Error != nil {
+			apiErr.Code, apiErr.Message, apiErr.Metadata = response.Error.Code, response.Error.Message, response.Error.Metadata
+		} else {
+			// Not from OpenRouter itself, like a proxy's error page
+			apiErr.Message = strings.ToValidUTF8(strings.TrimSpace(string(data[:min(len(data), 300)])), "")
+		}
+	}
+
+	return nil, apiErr
+}
+
+// Backoff between retries, doubling from the base up to the limit. Variables so tests don't wait.
+var (
+	retryBase  = time.Second
+	retryLimit = 30 * time.Second
+)
+
+// retryDelay reports whether a failed request should be retried, and after how long. Waiting
+// longer than the limit for a Retry-After is left to the caller.
+func retryDelay(ctx context.Context, err error, attempt int, retries int) (time.Duration, bool) {
+	if attempt >= retries || ctx.Err() != nil {
+		return 0, false
 	}
 
-	response := &Response{}
-	if err := json.NewDecoder(resp.Body).Decode(response); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		if !apiErr.Temporary() {
+			return 0, false
+		}
+		if apiErr.RetryAfter > 0 {
+			return apiErr.RetryAfter, apiErr.RetryAfter <= retryLimit
+		}
 	}
 
-	if response.Error != nil {
-		return nil, fmt.Errorf("API error: %s", response.Error.Message)
+	// Full jitter keeps the bot's concurrent requests from retrying in lockstep
+	backoff := min(retryBase<<attempt, retryLimit)
+	return backoff/2 + rand.N(backoff/2), true
+}
+
+// retryAfter parses a Retry-After header, in seconds or as a date.
+func retryAfter(header string) time.Duration {
+	if header == "" {
+		return 0
 	}
 
-	return response, nil
+	if seconds, err := strconv.Atoi(header); err == nil {
+		return time.Duration(max(seconds, 0)) * time.Second
+	}
+
+	if date, err := http.ParseTime(header); err == nil {
+		return max(time.Until(date), 0)
+	}
+
+	return 0
 }
 
 func (r *OpenRouter) NewRequest() *Request {
@@ -142,27 +382,10 @@ func (r *OpenRouter) NewRequest() *Request {
 		Model:       r.Model,
 		MaxTokens:   r.MaxTokens,
 		Temperature: r.Temperature,
+		Usage:       &UsageOptions{Include: true},
 		Messages: []*Message{
 			{Role: "system", Content: r.SystemPrompt},
 		},
-		Tools: []Tool{
-			{
-				Type: "function",
-				Fucntion: Function{
-					Name:        "search",
-					Description: "Search the internet for information",
-					Parameters: map[string]Parameters{
-						"query": {
-							Type: "string",
-							Properties: map[string]Type{
-								"query": {Type: "string"},
-							},
-							Required: []string{"query"},
-						},
-					},
-				},
-			},
-		},
 	}
 }
 
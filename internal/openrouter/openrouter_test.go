@@ -0,0 +1,299 @@
+package openrouter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fastRetries shortens the backoff for the test.
+func fastRetries(t *testing.T) {
+	base, limit := retryBase, retryLimit
+	retryBase, retryLimit = time.Millisecond, 2*time.Second
+	t.Cleanup(func() {
+		retryBase, retryLimit = base, limit
+	})
+}
+
+const okResponse = `{"id":"gen-1","model":"test/model","choices":[{"message":{"role":"assistant","content":"Hi!"}}]}`
+
+// failing answers with the status and body the first times, then with okResponse.
+func failing(times int, status int, header map[string]string, body string) (http.HandlerFunc, *atomic.Int32) {
+	attempts := &atomic.Int32{}
+	return func(w http.ResponseWriter, r *http.Request) {
+		if int(attempts.Add(1)) > times {
+			fmt.Fprint(w, okResponse)
+			return
+		}
+
+		for key, value := range header {
+			w.Header().Set(key, value)
+		}
+		w.WriteHeader(status)
+		fmt.Fprint(w, body)
+	}, attempts
+}
+
+func TestSendRetries(t *testing.T) {
+	fastRetries(t)
+
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		failures int
+		attempts int32
+		fails    bool
+		matches  error
+	}{
+		{"rate limited", http.StatusTooManyRequests, `{"error":{"code":429,"message":"Slow down"}}`, 2, 3, false, nil},
+		{"provider down", http.StatusBadGateway, `{"error":{"code":502,"message":"Provider returned error"}}`, 1, 2, false, nil},
+		{"unavailable", http.StatusServiceUnavailable, `<html>Service Unavailable</html>`, 3, 4, false, nil},
+		{"timeout", http.StatusRequestTimeout, `{"error":{"code":408,"message":"Timed out"}}`, 1, 2, false, nil},
+		{"gives up after the retries", http.StatusInternalServerError, `{"error":{"code":500,"message":"Oops"}}`, 10, 4, true, nil},
+		{"bad request", http.StatusBadRequest, `{"error":{"code":400,"message":"Invalid model"}}`, 10, 1, true, ErrBadRequest},
+		{"unauthorized", http.StatusUnauthorized, `{"error":{"code":401,"message":"No auth credentials found"}}`, 10, 1, true, ErrUnauthorized},
+		{"no credits", http.StatusPaymentRequired, `{"error":{"code":402,"message":"Insufficient credits"}}`, 10, 1, true, ErrInsufficientCredits},
+		{"moderated", http.StatusForbidden, `{"error":{"code":403,"message":"Flagged"}}`, 10, 1, true, ErrModerated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, attempts := failing(tt.failures, tt.status, nil, tt.body)
+			o := newTestClient(t, handler)
+
+			response, err := o.Send(t.Context(), newTestRequest(o))
+			if got := attempts.Load(); got != tt.attempts {
+				t.Errorf("sent %d times, want %d", got, tt.attempts)
+			}
+
+			if !tt.fails {
+				if err != nil {
+					t.Fatalf("Send failed: %v", err)
+				}
+				if content := response.Choices[0].Message.Content; content != "Hi!" {
+					t.Errorf("content = %q, want Hi!", content)
+				}
+				return
+			}
+
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("err = %v, want an APIError", err)
+			}
+			if apiErr.StatusCode != tt.status {
+				t.Errorf("status = %d, want %d", apiErr.StatusCode, tt.status)
+			}
+			if tt.matches != nil && !errors.Is(err, tt.matches) {
+				t.Errorf("err = %v, want it to match %v", err, tt.matches)
+			}
+		})
+	}
+}
+
+func TestSendRetryAfter(t *testing.T) {
+	fastRetries(t)
+
+	handler, attempts := failing(1, http.StatusTooManyRequests, map[string]string{"Retry-After": "1"}, `{"error":{"code":429,"message":"Slow down"}}`)
+	o := newTestClient(t, handler)
+
+	start := time.Now()
+	if _, err := o.Send(t.Context(), newTestRequest(o)); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("retried after %v, want the second Retry-After asked for", elapsed)
+	}
+	if got := attempts.Load(); got != 2 {
+		t.Errorf("sent %d times, want 2", got)
+	}
+}
+
+func TestSendRetryAfterTooLong(t *testing.T) {
+	fastRetries(t)
+
+	handler, attempts := failing(1, http.StatusTooManyRequests, map[string]string{"Retry-After": "3600"}, `{"error":{"code":429,"message":"Slow down"}}`)
+	o := newTestClient(t, handler)
+
+	_, err := o.Send(t.Context(), newTestRequest(o))
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.RetryAfter != time.Hour {
+		t.Fatalf("err = %v, want an APIError asking to retry after an hour", err)
+	}
+	if !errors.Is(err, ErrRateLimited) {
+		t.Errorf("err = %v, want ErrRateLimited", err)
+	}
+	if got := attempts.Load(); got != 1 {
+		t.Errorf("sent %d times, want the caller to decide about waiting that long", got)
+	}
+}
+
+func TestSendCancelledDuringBackoff(t *testing.T) {
+	handler, attempts := failing(10, http.StatusServiceUnavailable, map[string]string{"Retry-After": "10"}, `{"error":{"code":503,"message":"Overloaded"}}`)
+	o := newTestClient(t, handler)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err := o.Send(ctx, newTestRequest(o))
+
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Send returned after %v, want right after the cancel", elapsed)
+	}
+	if !errors.Is(err, ErrUnavailable) {
+		t.Errorf("err = %v, want the last error, ErrUnavailable", err)
+	}
+	if got := attempts.Load(); got != 1 {
+		t.Errorf("sent %d times, want 1", got)
+	}
+}
+
+func TestSendErrors(t *testing.T) {
+	long := strings.Repeat("x", 1000)
+
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		want    APIError
+		matches error
+	}{
+		{
+			name:    "OpenRouter error",
+			status:  http.StatusBadRequest,
+			body:    `{"error":{"code":400,"message":"response_format is not supported","metadata":{"provider_name":"Test"}}}`,
+			want:    APIError{StatusCode: 400, Code: 400, Message: "response_format is not supported", Metadata: map[string]any{"provider_name": "Test"}},
+			matches: ErrBadRequest,
+		},
+		{
+			name:    "error in the body of a response",
+			status:  http.StatusOK,
+			body:    `{"error":{"code":502,"message":"Provider returned error"}}`,
+			want:    APIError{StatusCode: 200, Code: 502, Message: "Provider returned error"},
+			matches: ErrProviderDown,
+		},
+		{
+			name:    "error page of a proxy",
+			status:  http.StatusUnauthorized,
+			body:    "  " + long,
+			want:    APIError{StatusCode: 401, Message: long[:298]},
+			matches: ErrUnauthorized,
+		},
+		{
+			name:   "unknown status",
+			status: http.StatusTeapot,
+			body:   "",
+			want:   APIError{StatusCode: 418},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			})
+			o.MaxRetries = 0
+
+			_, err := o.Send(t.Context(), newTestRequest(o))
+
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("err = %v, want an APIError", err)
+			}
+			if apiErr.StatusCode != tt.want.StatusCode || apiErr.Code != tt.want.Code || apiErr.Message != tt.want.Message {
+				t.Errorf("err = %+v, want %+v", apiErr, tt.want)
+			}
+			if fmt.Sprint(apiErr.Metadata) != fmt.Sprint(tt.want.Metadata) {
+				t.Errorf("metadata = %v, want %v", apiErr.Metadata, tt.want.Metadata)
+			}
+			if tt.matches != nil && !errors.Is(err, tt.matches) {
+				t.Errorf("err = %v, want it to match %v", err, tt.matches)
+			}
+		})
+	}
+}
+
+func TestSendFallbacks(t *testing.T) {
+	fastRetries(t)
+
+	tests := []struct {
+		name      string
+		status    int
+		requested []string
+		err       error
+	}{
+		{"rate limited models give way right away", http.StatusTooManyRequests, []string{"test/model", "test/first", "test/second"}, nil},
+		{"bad requests try the next model", http.StatusBadRequest, []string{"test/model", "test/first", "test/second"}, nil},
+		{"no credits for any model", http.StatusPaymentRequired, []string{"test/model"}, ErrInsufficientCredits},
+		{"invalid key for any model", http.StatusUnauthorized, []string{"test/model"}, ErrUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requested := &models{}
+			o := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				model := decodeRequest(t, r).Model
+				requested.add(model)
+
+				if model != "test/second" {
+					w.WriteHeader(tt.status)
+					fmt.Fprintf(w, `{"error":{"code":%d,"message":"Failed"}}`, tt.status)
+					return
+				}
+				fmt.Fprint(w, okResponse)
+			})
+			o.Fallbacks = []string{"test/first", "test/model", "test/second"}
+
+			r := newTestRequest(o)
+			_, err := o.Send(t.Context(), r)
+
+			if !slices.Equal(requested.list(), tt.requested) {
+				t.Errorf("requested models %q, want %q", requested.list(), tt.requested)
+			}
+			if tt.err == nil && err != nil {
+				t.Errorf("Send failed: %v", err)
+			}
+			if tt.err != nil && !errors.Is(err, tt.err) {
+				t.Errorf("err = %v, want %v", err, tt.err)
+			}
+			if r.Model != "test/model" {
+				t.Errorf("the request's model is %s after the fallbacks, want it restored", r.Model)
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		header string
+		want   time.Duration
+	}{
+		{"", 0},
+		{"5", 5 * time.Second},
+		{"-5", 0},
+		{"soon", 0},
+		{time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat), 0},
+	}
+
+	for _, tt := range tests {
+		if got := retryAfter(tt.header); got != tt.want {
+			t.Errorf("retryAfter(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+
+	// Dates only have seconds
+	date := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+	if got := retryAfter(date); got < 58*time.Second || got > time.Minute {
+		t.Errorf("retryAfter(%q) = %v, want about a minute", date, got)
+	}
+}
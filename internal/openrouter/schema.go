@@ -0,0 +1,84 @@
+package openrouter
+
+import "encoding/json"
+
+// Schema is the subset of JSON Schema used to describe tool parameters.
+type Schema struct {
+	Type        string             `json:"type,omitempty"`
+	Description string             `json:"description,omitempty"`
+	Properties  map[string]*Schema `json:"properties,omitempty"`
+	Required    []string           `json:"required,omitempty"`
+	Enum        []any              `json:"enum,omitempty"`
+	Items       *Schema            `json:"items,omitempty"`
+}
+
+// MarshalJSON always emits properties for objects, some providers reject objects without them.
+func (s *Schema) MarshalJSON() ([]byte, error) {
+	type schema Schema
+	if s.Type != "object" {
+		return json.Marshal((*schema)(s))
+	}
+
+	properties := s.Properties
+	if properties == nil {
+		properties = map[string]*Schema{}
+	}
+
+	return json.Marshal(struct {
+		*schema
+		Properties map[string]*Schema `json:"properties"`
+	}{(*schema)(s), properties})
+}
+
+func Object() *Schema {
+	return &Schema{Type: "object", Properties: map[string]*Schema{}}
+}
+
+func String(description string) *Schema {
+	return &Schema{Type: "string", Description: description}
+}
+
+func Integer(description string) *Schema {
+	return &Schema{Type: "integer", Description: description}
+}
+
+func Number(description string) *Schema {
+	return &Schema{Type: "number", Description: description}
+}
+
+func Boolean(description string) *Schema {
+	return &Schema{Type: "boolean", Description: description}
+}
+
+func Array(items *Schema, description string) *Schema {
+	return &Schema{Type: "array", Items: items, Description: description}
+}
+
+// Property adds an optional property to an object schema.
+func (s *Schema) Property(name string, property *Schema) *Schema {
+	if s.Properties == nil {
+		s.Properties = map[string]*Schema{}
+	}
+
+	s.Properties[name] = property
+	return s
+}
+
+// RequiredProperty adds a property the model must always provide.
+func (s *Schema) RequiredProperty(name string, property *Schema) *Schema {
+	s.Property(name, property)
+	s.Required = append(s.Required, name)
+	return s
+}
+
+// OneOf restricts the schema to the given values.
+func (s *Schema) OneOf(values ...any) *Schema {
+	s.Enum = append(s.Enum, values...)
+	return s
+}
+
+// Describe sets the description of the schema.
+func (s *Schema) Describe(description string) *Schema {
+	s.Description = description
+	return s
+}
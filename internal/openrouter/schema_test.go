@@ -0,0 +1,80 @@
+package openrouter
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files with the current output")
+
+// checkGolden compares the value's indented JSON to testdata/name.
+func checkGolden(t *testing.T, name string, value any) {
+	t.Helper()
+
+	got, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		t.Fatalf("failed to marshal: %v", err)
+	}
+	got = append(got, '\n')
+
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatalf("failed to update %s: %v", path, err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", path, err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s does not match, got:\n%s\nwant:\n%s", path, got, want)
+	}
+}
+
+func TestToolGolden(t *testing.T) {
+	tools := []Tool{
+		{
+			Type: "function",
+			Function: Function{
+				Name:        "web_search",
+				Description: "Search the web",
+				Parameters: Object().
+					RequiredProperty("query", String("What to search for")).
+					Property("count", Integer("How many results to return")).
+					Property("freshness", String("How recent the results must be").OneOf("day", "week", "month")).
+					Property("safe", Boolean("Whether to filter explicit results")).
+					Property("sites", Array(String(""), "Only search these sites")),
+			},
+		},
+		{
+			Type: "function",
+			Function: Function{
+				Name:        "current_time",
+				Description: "Get the current time",
+				Parameters:  &Schema{Type: "object"},
+			},
+		},
+	}
+
+	checkGolden(t, "tools.json", tools)
+}
+
+func TestResponseFormatGolden(t *testing.T) {
+	schema := Object().
+		RequiredProperty("verdict", String("The verdict").OneOf("true", "false", "unclear")).
+		RequiredProperty("score", Number("From 0 to 1")).
+		RequiredProperty("evidence", Array(Object().
+			RequiredProperty("quote", String("A quote from a source")).
+			RequiredProperty("sources", Array(Integer("Search result number"), "")),
+			"Evidence for and against")).
+		Property("context", String("").Describe("Missing context"))
+
+	checkGolden(t, "response_format.json", JSONResponse("fact_check", schema))
+}
@@ -0,0 +1,199 @@
+package openrouter
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type StreamChunk struct {
+	ID      string          `json:"id"`
+	Model   string          `json:"model,omitempty"`
+	Usage   *Usage          `json:"usage,omitempty"` // Only in the last chunk
+	Choices []*StreamChoice `json:"choices"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+type StreamChoice struct {
+	Delta        Delta  `json:"delta"`
+	FinishReason string `json:"finish_reason,omitempty"`
+}
+
+type Delta struct {
+	Role      string          `json:"role,omitempty"`
+	Content   string          `json:"content,omitempty"`
+	ToolCalls []ToolCallDelta `json:"tool_calls,omitempty"`
+}
+
+// ToolCallDelta is a fragment of a tool call. Fragments sharing the same index
+// belong to the same call and their arguments are concatenated.
+type ToolCallDelta struct {
+	Index    int          `json:"index"`
+	ID       string       `json:"id,omitempty"`
+	Type     string       `json:"type,omitempty"`
+	Function FunctionCall `json:"function"`
+}
+
+// Stream sends the request as a server-sent events stream and calls onDelta for every
+// delta received. The returned response holds the assembled message, as Send would.
+// A cached response is delivered as a single delta.
+func (o *OpenRouter) Stream(ctx context.Context, r *Request, onDelta func(delta *Delta)) (*Response, error) {
+	key, cacheable := o.cacheKey(r)
+	if cacheable {
+		if response, ok := o.Cache.Get(key); ok {
+			if onDelta != nil && len(response.Choices) > 0 {
+				message := response.Choices[0].Message
+				onDelta(&Delta{Role: message.Role, Content: message.Content})
+			}
+			return response, nil
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+	defer cancel()
+
+	r.Stream = true
+	defer func() { r.Stream = false }()
+
+	// Once deltas were delivered the next model would repeat them, so only failures before that fall back
+	delivered := false
+	deliver := func(delta *Delta) {
+		delivered = true
+		if onDelta != nil {
+			onDelta(delta)
+		}
+	}
+
+	var response *Response
+	err := o.fallback(ctx, r, func(retries int) error {
+		resp, err := o.post(ctx, r, retries)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		response, err = readStream(resp.Body, deliver)
+		if err != nil && delivered {
+			return &streamError{err}
+		}
+		return err
+	})
+
+	var partial *streamError
+	if errors.As(err, &partial) {
+		err = partial.err
+	}
+
+	if err == nil && o.OnResponse != nil {
+		o.OnResponse(response)
+	}
+
+	if err == nil && cacheable {
+		o.Cache.Set(key, response)
+	}
+
+	return response, err
+}
+
+func readStream(body io.Reader, onDelta func(delta *Delta)) (*Response, error) {
+	choice := &Choice{Message: Message{Role: "assistant"}}
+	response := &Response{}
+	content := &strings.Builder{}
+	arguments := map[int]*strings.Builder{}
+
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		// Lines starting with a colon are comments OpenRouter sends to keep the connection alive
+		data, ok := strings.CutPrefix(line, "data:")
+		if !ok {
+			continue
+		}
+
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			break
+		}
+
+		chunk := &StreamChunk{}
+		if err := json.Unmarshal([]byte(data), chunk); err != nil {
+			return nil, fmt.Errorf("failed to decode stream chunk: %w", err)
+		}
+
+		if chunk.Error != nil {
+			return nil, chunk.Error.apiError(http.StatusOK)
+		}
+
+		if chunk.Model != "" {
+			response.ID, response.Model = chunk.ID, chunk.Model
+		}
+		if chunk.Usage != nil {
+			response.Usage = chunk.Usage
+		}
+
+		for _, c := range chunk.Choices {
+			content.WriteString(c.Delta.Content)
+
+			for _, call := range c.Delta.ToolCalls {
+				for len(choice.Message.ToolCalls) <= call.Index {
+					choice.Message.ToolCalls = append(choice.Message.ToolCalls, ToolCall{Type: "function"})
+					arguments[len(choice.Message.ToolCalls)-1] = &strings.Builder{}
+				}
+
+				toolCall := &choice.Message.ToolCalls[call.Index]
+				if call.ID != "" {
+					toolCall.ID = call.ID
+				}
+				if call.Type != "" {
+					toolCall.Type = call.Type
+				}
+				if call.Function.Name != "" {
+					toolCall.Function.Name = call.Function.Name
+				}
+				arguments[call.Index].WriteString(call.Function.Arguments)
+			}
+
+			if c.FinishReason != "" {
+				choice.FinishReason = c.FinishReason
+			}
+
+			if onDelta != nil {
+				onDelta(&c.Delta)
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	choice.Message.Content = content.String()
+	for i := range choice.Message.ToolCalls {
+		choice.Message.ToolCalls[i].Function.Arguments = arguments[i].String()
+	}
+
+	response.Choices = []*Choice{choice}
+	return response, nil
+}
+
+// streamError is a stream that failed after delivering deltas, it can't fall back to another model.
+type streamError struct {
+	err error
+}
+
+func (e *streamError) Error() string {
+	return e.err.Error()
+}
+
+func (e *streamError) Unwrap() error {
+	return e.err
+}
@@ -0,0 +1,235 @@
+package openrouter
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// newTestClient returns a client that sends its requests to the handler.
+func newTestClient(t *testing.T, handler http.HandlerFunc) *OpenRouter {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	o := New("test-key", "You are a test", nil, 0, 0, "test/model")
+	o.BaseURL = server.URL
+	o.Client = server.Client()
+	return o
+}
+
+func newTestRequest(o *OpenRouter) *Request {
+	r := o.NewRequest()
+	r.AddMessage("user", "Hello")
+	return r
+}
+
+// decodeRequest reads the request the client sent.
+func decodeRequest(t *testing.T, r *http.Request) *Request {
+	t.Helper()
+
+	request := &Request{}
+	if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+		t.Errorf("failed to decode request: %v", err)
+	}
+	return request
+}
+
+// writeEvents writes the lines as a server-sent events stream.
+func writeEvents(w http.ResponseWriter, lines ...string) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	for _, line := range lines {
+		fmt.Fprintf(w, "%s\n\n", line)
+		w.(http.Flusher).Flush()
+	}
+}
+
+// models records the models requested, in order.
+type models struct {
+	mutex sync.Mutex
+	names []string
+}
+
+func (m *models) add(name string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.names = append(m.names, name)
+}
+
+func (m *models) list() []string {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return slices.Clone(m.names)
+}
+
+func TestStreamContent(t *testing.T) {
+	o := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if request := decodeRequest(t, r); !request.Stream {
+			t.Errorf("request was not streamed")
+		}
+
+		writeEvents(w,
+			": OPENROUTER PROCESSING",
+			`data: {"id":"gen-1","model":"test/model","choices":[{"delta":{"role":"assistant"}}]}`,
+			`data: {"id":"gen-1","model":"test/model","choices":[{"delta":{"content":"Hel"}}]}`,
+			`data: {"id":"gen-1","model":"test/model","choices":[{"delta":{"content":"lo!"},"finish_reason":"stop"}]}`,
+			`data: {"id":"gen-1","model":"test/model","choices":[],"usage":{"prompt_tokens":12,"completion_tokens":3,"total_tokens":15,"cost":0.0002}}`,
+			"data: [DONE]",
+			`data: {"choices":[{"delta":{"content":" after the end"}}]}`,
+		)
+	})
+
+	var responses []*Response
+	o.OnResponse = func(response *Response) {
+		responses = append(responses, response)
+	}
+
+	deltas := []string{}
+	r := newTestRequest(o)
+	response, err := o.Stream(t.Context(), r, func(delta *Delta) {
+		deltas = append(deltas, delta.Content)
+	})
+	if err != nil {
+		t.Fatalf("Stream failed: %v", err)
+	}
+
+	if want := []string{"", "Hel", "lo!"}; !slices.Equal(deltas, want) {
+		t.Errorf("deltas = %q, want %q", deltas, want)
+	}
+
+	message := response.Choices[0].Message
+	if message.Role != "assistant" || message.Content != "Hello!" {
+		t.Errorf("message = %s %q, want assistant %q", message.Role, message.Content, "Hello!")
+	}
+	if reason := response.Choices[0].FinishReason; reason != "stop" {
+		t.Errorf("finish reason = %q, want stop", reason)
+	}
+	if response.ID != "gen-1" || response.Model != "test/model" {
+		t.Errorf("response is %s by %s, want gen-1 by test/model", response.ID, response.Model)
+	}
+
+	want := Usage{PromptTokens: 12, CompletionTokens: 3, TotalTokens: 15, Cost: 0.0002}
+	if response.Usage == nil || *response.Usage != want {
+		t.Errorf("usage = %+v, want %+v", response.Usage, want)
+	}
+
+	if len(responses) != 1 || responses[0] != response {
+		t.Errorf("OnResponse was called %d times, want once with the response", len(responses))
+	}
+	if r.Stream {
+		t.Errorf("the request is still marked as a stream")
+	}
+}
+
+func TestStreamToolCalls(t *testing.T) {
+	o := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeEvents(w,
+			`data: {"choices":[{"delta":{"role":"assistant","tool_calls":[{"index":0,"id":"call-1","type":"function","function":{"name":"web_search","arguments":""}}]}}]}`,
+			`data: {"choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"query\":"}}]}}]}`,
+			`data: {"choices":[{"delta":{"tool_calls":[{"index":1,"id":"call-2","type":"function","function":{"name":"web_search","arguments":"{\"query\":\"go"}}]}}]}`,
+			`data: {"choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"weather\"}"}}]}}]}`,
+			`data: {"choices":[{"delta":{"tool_calls":[{"index":1,"function":{"arguments":"lang\"}"}}]},"finish_reason":"tool_calls"}]}`,
+			"data: [DONE]",
+		)
+	})
+
+	response, err := o.Stream(t.Context(), newTestRequest(o), nil)
+	if err != nil {
+		t.Fatalf("Stream failed: %v", err)
+	}
+
+	want := []ToolCall{
+		{ID: "call-1", Type: "function", Function: FunctionCall{Name: "web_search", Arguments: `{"query":"weather"}`}},
+		{ID: "call-2", Type: "function", Function: FunctionCall{Name: "web_search", Arguments: `{"query":"golang"}`}},
+	}
+	if calls := response.Choices[0].Message.ToolCalls; !slices.Equal(calls, want) {
+		t.Errorf("tool calls = %+v, want %+v", calls, want)
+	}
+	if reason := response.Choices[0].FinishReason; reason != "tool_calls" {
+		t.Errorf("finish reason = %q, want tool_calls", reason)
+	}
+}
+
+func TestStreamErrorAfterOutput(t *testing.T) {
+	requested := &models{}
+	o := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requested.add(decodeRequest(t, r).Model)
+		writeEvents(w,
+			`data: {"choices":[{"delta":{"content":"Half an ans"}}]}`,
+			`data: {"error":{"code":502,"message":"Provider disconnected"}}`,
+		)
+	})
+	o.Fallbacks = []string{"test/fallback"}
+	o.OnResponse = func(response *Response) {
+		t.Errorf("OnResponse was called for a failed stream")
+	}
+
+	content := &strings.Builder{}
+	_, err := o.Stream(t.Context(), newTestRequest(o), func(delta *Delta) {
+		content.WriteString(delta.Content)
+	})
+
+	if !errors.Is(err, ErrProviderDown) {
+		t.Errorf("err = %v, want ErrProviderDown", err)
+	}
+	var partial *streamError
+	if errors.As(err, &partial) {
+		t.Errorf("the internal stream error leaked to the caller: %v", err)
+	}
+
+	// The fallback would repeat what was shown already
+	if want := []string{"test/model"}; !slices.Equal(requested.list(), want) {
+		t.Errorf("requested models %q, want %q", requested.list(), want)
+	}
+	if content.String() != "Half an ans" {
+		t.Errorf("delivered %q, want the partial output once", content.String())
+	}
+}
+
+func TestStreamErrorBeforeOutput(t *testing.T) {
+	requested := &models{}
+	o := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		model := decodeRequest(t, r).Model
+		requested.add(model)
+
+		if model == "test/model" {
+			writeEvents(w, `data: {"error":{"code":503,"message":"No provider available"}}`)
+			return
+		}
+
+		writeEvents(w,
+			`data: {"model":"test/fallback","choices":[{"delta":{"content":"From the fallback"}}]}`,
+			"data: [DONE]",
+		)
+	})
+	o.Fallbacks = []string{"test/fallback"}
+
+	response, err := o.Stream(t.Context(), newTestRequest(o), nil)
+	if err != nil {
+		t.Fatalf("Stream failed: %v", err)
+	}
+
+	if want := []string{"test/model", "test/fallback"}; !slices.Equal(requested.list(), want) {
+		t.Errorf("requested models %q, want %q", requested.list(), want)
+	}
+	if response.Model != "test/fallback" || response.Choices[0].Message.Content != "From the fallback" {
+		t.Errorf("response = %q by %s, want the fallback's", response.Choices[0].Message.Content, response.Model)
+	}
+}
+
+func TestStreamInvalidChunk(t *testing.T) {
+	o := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeEvents(w, "data: {not json")
+	})
+
+	if _, err := o.Stream(t.Context(), newTestRequest(o), nil); err == nil {
+		t.Errorf("Stream accepted an invalid chunk")
+	}
+}
@@ -0,0 +1,150 @@
+package openrouter
+
+import (
+	"encoding/json"
+	"math"
+	"slices"
+	"sort"
+	"unicode/utf8"
+)
+
+// Tokenizer estimates how many tokens a model reads for a text.
+type Tokenizer interface {
+	Count(text string) int
+}
+
+// Heuristic estimates tokens without knowing the model's vocabulary. English
+// averages about four characters per token, while most other scripts and emoji
+// take a token or more per character.
+type Heuristic struct {
+	CharsPerToken float64 // For ASCII text, 4 when 0
+}
+
+func (h Heuristic) Count(text string) int {
+	charsPerToken := h.CharsPerToken
+	if charsPerToken <= 0 {
+		charsPerToken = 4
+	}
+
+	ascii, other := 0, 0
+	for _, r := range text {
+		if r < utf8.RuneSelf {
+			ascii++
+		} else {
+			other++
+		}
+	}
+
+	return int(math.Ceil(float64(ascii)/charsPerToken)) + other
+}
+
+// Tokens the chat format adds to every message, for the role and separators.
+const messageOverhead = 4
+
+// Tokens the model answers with when the request doesn't limit them, as far as the
+// context is concerned.
+const defaultOutputTokens = 1024
+
+// Less room than this isn't worth cutting the oldest message down to.
+const minMessageTokens = 32
+
+const truncatedMarker = " […]"
+
+func (o *OpenRouter) tokenizer() Tokenizer {
+	if o.Tokenizer == nil {
+		return Heuristic{}
+	}
+
+	return o.Tokenizer
+}
+
+// CountMessage estimates the tokens of a message in a request.
+func (o *OpenRouter) CountMessage(m *Message) int {
+	tokens := messageOverhead + o.tokenizer().Count(m.Content)
+	for _, call := range m.ToolCalls {
+		tokens += o.tokenizer().Count(call.Function.Name) + o.tokenizer().Count(call.Function.Arguments)
+	}
+
+	return tokens
+}
+
+// CountRequest estimates the tokens of everything the request sends, and the room
+// it leaves for the answer.
+func (o *OpenRouter) CountRequest(r *Request) int {
+	tokens := 0
+	for _, m := range r.Messages {
+		tokens += o.CountMessage(m)
+	}
+
+	if len(r.Tools) > 0 {
+		if b, err := json.Marshal(r.Tools); err == nil {
+			tokens += o.tokenizer().Count(string(b))
+		}
+	}
+
+	if r.MaxTokens > 0 {
+		return tokens + r.MaxTokens
+	}
+
+	return tokens + defaultOutputTokens
+}
+
+// FitContext inserts the messages, oldest first, after the system prompt of the request.
+// Starting with the newest it takes as many as fit in ContextTokens next to the rest of
+// the request, its tools and its answer. Messages longer than MaxMessageTokens are cut
+// down, and so is the oldest one when only part of it fits. It returns how many of the
+// newest messages it took.
+func (o *OpenRouter) FitContext(r *Request, messages []*Message) int {
+	budget := math.MaxInt
+	if o.ContextTokens > 0 {
+		budget = o.ContextTokens - o.CountRequest(r)
+	}
+
+	fitted := []*Message{}
+	for i := len(messages) - 1; i >= 0 && budget > 0; i-- {
+		m := messages[i]
+		if o.MaxMessageTokens > 0 && o.CountMessage(m) > o.MaxMessageTokens {
+			m = o.truncate(m, o.MaxMessageTokens)
+		}
+
+		tokens := o.CountMessage(m)
+		if tokens > budget {
+			if budget >= minMessageTokens {
+				fitted = append(fitted, o.truncate(m, budget))
+			}
+			break
+		}
+
+		fitted = append(fitted, m)
+		budget -= tokens
+	}
+
+	slices.Reverse(fitted)
+
+	// After the system prompts, before the prompt the context is for
+	at := 0
+	for at < len(r.Messages) && r.Messages[at].Role == "system" {
+		at++
+	}
+	r.Messages = slices.Insert(r.Messages, at, fitted...)
+
+	return len(fitted)
+}
+
+// truncate returns a copy of the message cut down to at most the tokens. It keeps the
+// beginning, where chat messages have their author.
+func (o *OpenRouter) truncate(m *Message, tokens int) *Message {
+	runes := []rune(m.Content)
+	content := func(n int) string {
+		return string(runes[:n]) + truncatedMarker
+	}
+
+	// The longest prefix that fits
+	n := max(0, sort.Search(len(runes)+1, func(n int) bool {
+		return messageOverhead+o.tokenizer().Count(content(n)) > tokens
+	})-1)
+
+	truncated := *m
+	truncated.Content = content(n)
+	return &truncated
+}
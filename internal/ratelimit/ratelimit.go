@@ -0,0 +1,163 @@
+package ratelimit
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// Scope is what a budget is shared by.
+type Scope string
+
+const (
+	User    Scope = "user"
+	Guild   Scope = "guild"
+	Channel Scope = "channel"
+)
+
+var scopes = []Scope{User, Guild, Channel}
+
+// Limit allows Max units of cost per sliding Window. A zero Max disables the scope.
+type Limit struct {
+	Max    int
+	Window time.Duration
+}
+
+// Request describes who is spending how much. Scopes with an empty ID are not charged.
+type Request struct {
+	UserID    string
+	GuildID   string
+	ChannelID string
+	Cost      int
+}
+
+func (r Request) id(scope Scope) string {
+	switch scope {
+	case User:
+		return r.UserID
+	case Guild:
+		return r.GuildID
+	case Channel:
+		return r.ChannelID
+	}
+
+	return ""
+}
+
+// Result tells whether a request was allowed, and otherwise which scope denied it and for how long.
+type Result struct {
+	Allowed    bool
+	Scope      Scope
+	RetryAfter time.Duration
+}
+
+// Limiter is a sliding-window limiter. Every unit of cost spent is remembered until it
+// leaves the window, so the budget frees up gradually instead of resetting all at once.
+type Limiter struct {
+	// Clock returns the current time, it can be replaced to control time.
+	Clock func() time.Time
+
+	mutex  sync.Mutex
+	limits map[Scope]Limit
+	usage  map[string][]int64 // "<scope>:<id>" to the unix time each spent unit expires, oldest first
+}
+
+func New(limits map[Scope]Limit) *Limiter {
+	return &Limiter{
+		Clock:  time.Now,
+		limits: limits,
+		usage:  map[string][]int64{},
+	}
+}
+
+// Allow spends the request cost from every scope it applies to, or from none if any of them is exhausted.
+// A cost above a scope's limit is capped to it, so expensive requests are slow but never impossible.
+func (l *Limiter) Allow(r Request) Result {
+	if r.Cost <= 0 {
+		return Result{Allowed: true}
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := l.Clock().Unix()
+	for _, scope := range scopes {
+		limit, id := l.limits[scope], r.id(scope)
+		if limit.Max <= 0 || id == "" {
+			continue
+		}
+
+		key := string(scope) + ":" + id
+		usage := l.expire(key, now)
+		cost := min(r.Cost, limit.Max)
+		if len(usage)+cost > limit.Max {
+			// The request fits once enough of the oldest units expire
+			retry := usage[len(usage)+cost-limit.Max-1] - now
+			return Result{Scope: scope, RetryAfter: time.Duration(retry) * time.Second}
+		}
+	}
+
+	for _, scope := range scopes {
+		limit, id := l.limits[scope], r.id(scope)
+		if limit.Max <= 0 || id == "" {
+			continue
+		}
+
+		key := string(scope) + ":" + id
+		expires := now + int64(limit.Window/time.Second)
+		for range min(r.Cost, limit.Max) {
+			l.usage[key] = append(l.usage[key], expires)
+		}
+	}
+
+	return Result{Allowed: true}
+}
+
+// expire drops the units that left the window. The caller must hold the lock.
+func (l *Limiter) expire(key string, now int64) []int64 {
+	usage := l.usage[key]
+	i := 0
+	for i < len(usage) && usage[i] <= now {
+		i++
+	}
+
+	if i == len(usage) {
+		delete(l.usage, key)
+		return nil
+	}
+
+	usage = usage[i:]
+	l.usage[key] = usage
+	return usage
+}
+
+// Snapshot returns the units still in their window, keyed by "<scope>:<id>".
+func (l *Limiter) Snapshot() map[string][]int64 {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := l.Clock().Unix()
+	snapshot := map[string][]int64{}
+	for key := range l.usage {
+		if usage := l.expire(key, now); usage != nil {
+			snapshot[key] = slices.Clone(usage)
+		}
+	}
+
+	return snapshot
+}
+
+// Restore replaces the usage with a snapshot.
+func (l *Limiter) Restore(snapshot map[string][]int64) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.usage = map[string][]int64{}
+	now := l.Clock().Unix()
+	for key, usage := range snapshot {
+		usage = slices.Clone(usage)
+		slices.Sort(usage)
+		l.usage[key] = usage
+		l.expire(key, now)
+	}
+}
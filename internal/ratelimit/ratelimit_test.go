@@ -0,0 +1,281 @@
+package ratelimit
+
+import (
+	"maps"
+	"slices"
+	"testing"
+	"time"
+)
+
+// clock is a time that only moves when told to.
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+func (c *clock) advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func newTestLimiter(limits map[Scope]Limit) (*Limiter, *clock) {
+	c := &clock{now: time.Unix(1_700_000_000, 0)}
+	l := New(limits)
+	l.Clock = c.Now
+	return l, c
+}
+
+// step is a request made after the clock advanced by wait, and what it should result in.
+type step struct {
+	wait    time.Duration
+	request Request
+	want    Result
+}
+
+func runSteps(t *testing.T, l *Limiter, c *clock, steps []step) {
+	t.Helper()
+
+	for i, s := range steps {
+		c.advance(s.wait)
+		if got := l.Allow(s.request); got != s.want {
+			t.Errorf("step %d: Allow(%+v) = %+v, want %+v", i, s.request, got, s.want)
+		}
+	}
+}
+
+func TestSlidingWindow(t *testing.T) {
+	alice := Request{UserID: "alice", Cost: 1}
+	allowed := Result{Allowed: true}
+
+	tests := []struct {
+		name  string
+		limit Limit
+		steps []step
+	}{
+		{
+			name:  "within the limit",
+			limit: Limit{Max: 3, Window: time.Minute},
+			steps: []step{
+				{0, alice, allowed},
+				{time.Second, alice, allowed},
+				{time.Second, alice, allowed},
+			},
+		},
+		{
+			name:  "over the limit until the oldest unit expires",
+			limit: Limit{Max: 2, Window: time.Minute},
+			steps: []step{
+				{0, alice, allowed},
+				{10 * time.Second, alice, allowed},
+				{0, alice, Result{Scope: User, RetryAfter: 50 * time.Second}},
+				{49 * time.Second, alice, Result{Scope: User, RetryAfter: time.Second}},
+				{time.Second, alice, allowed},
+				// The second unit still holds the budget
+				{0, alice, Result{Scope: User, RetryAfter: 10 * time.Second}},
+			},
+		},
+		{
+			name:  "frees up gradually",
+			limit: Limit{Max: 2, Window: time.Minute},
+			steps: []step{
+				{0, alice, allowed},
+				{30 * time.Second, alice, allowed},
+				{30 * time.Second, alice, allowed},
+				{0, alice, Result{Scope: User, RetryAfter: 30 * time.Second}},
+				{30 * time.Second, alice, allowed},
+			},
+		},
+		{
+			name:  "denied requests cost nothing",
+			limit: Limit{Max: 1, Window: time.Minute},
+			steps: []step{
+				{0, alice, allowed},
+				{30 * time.Second, alice, Result{Scope: User, RetryAfter: 30 * time.Second}},
+				{20 * time.Second, alice, Result{Scope: User, RetryAfter: 10 * time.Second}},
+				{10 * time.Second, alice, allowed},
+			},
+		},
+		{
+			name:  "users have their own budget",
+			limit: Limit{Max: 1, Window: time.Minute},
+			steps: []step{
+				{0, alice, allowed},
+				{0, Request{UserID: "bob", Cost: 1}, allowed},
+				{0, alice, Result{Scope: User, RetryAfter: time.Minute}},
+			},
+		},
+		{
+			name:  "disabled",
+			limit: Limit{Max: 0, Window: time.Minute},
+			steps: []step{
+				{0, alice, allowed},
+				{0, alice, allowed},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, c := newTestLimiter(map[Scope]Limit{User: tt.limit})
+			runSteps(t, l, c, tt.steps)
+		})
+	}
+}
+
+func TestCosts(t *testing.T) {
+	allowed := Result{Allowed: true}
+	cost := func(n int) Request {
+		return Request{UserID: "alice", Cost: n}
+	}
+
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "expensive requests use more of the budget",
+			steps: []step{
+				{0, cost(3), allowed},
+				{10 * time.Second, cost(2), allowed},
+				{0, cost(1), Result{Scope: User, RetryAfter: 50 * time.Second}},
+			},
+		},
+		{
+			name: "waits for as many units as the request costs",
+			steps: []step{
+				{0, cost(2), allowed},
+				{10 * time.Second, cost(2), allowed},
+				{10 * time.Second, cost(1), allowed},
+				// Needs two units back, the second pair expires 10 seconds after the first
+				{0, cost(2), Result{Scope: User, RetryAfter: 40 * time.Second}},
+				{40 * time.Second, cost(2), allowed},
+			},
+		},
+		{
+			name: "costs above the limit are capped",
+			steps: []step{
+				{0, cost(50), allowed},
+				{0, cost(1), Result{Scope: User, RetryAfter: time.Minute}},
+				{time.Minute, cost(50), allowed},
+			},
+		},
+		{
+			name: "free requests are always allowed",
+			steps: []step{
+				{0, cost(5), allowed},
+				{0, cost(0), allowed},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, c := newTestLimiter(map[Scope]Limit{User: {Max: 5, Window: time.Minute}})
+			runSteps(t, l, c, tt.steps)
+		})
+	}
+}
+
+func TestScopes(t *testing.T) {
+	allowed := Result{Allowed: true}
+	limits := map[Scope]Limit{
+		User:    {Max: 3, Window: time.Minute},
+		Guild:   {Max: 4, Window: time.Minute},
+		Channel: {Max: 2, Window: 30 * time.Second},
+	}
+	request := func(user string, channel string) Request {
+		return Request{UserID: user, GuildID: "guild", ChannelID: channel, Cost: 1}
+	}
+
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "the channel runs out first",
+			steps: []step{
+				{0, request("alice", "general"), allowed},
+				{0, request("bob", "general"), allowed},
+				{0, request("carol", "general"), Result{Scope: Channel, RetryAfter: 30 * time.Second}},
+				{0, request("carol", "random"), allowed},
+			},
+		},
+		{
+			name: "the guild is shared by all channels",
+			steps: []step{
+				{0, request("alice", "general"), allowed},
+				{0, request("bob", "random"), allowed},
+				{0, request("carol", "memes"), allowed},
+				{0, request("dave", "news"), allowed},
+				{0, request("erin", "help"), Result{Scope: Guild, RetryAfter: time.Minute}},
+			},
+		},
+		{
+			name: "the user is checked before the guild",
+			steps: []step{
+				{0, request("alice", "general"), allowed},
+				{0, request("alice", "random"), allowed},
+				{0, request("alice", "memes"), allowed},
+				{0, request("alice", "news"), Result{Scope: User, RetryAfter: time.Minute}},
+				{0, request("bob", "news"), allowed},
+			},
+		},
+		{
+			name: "a denied request costs no scope anything",
+			steps: []step{
+				{0, request("alice", "general"), allowed},
+				{0, request("bob", "general"), allowed},
+				{0, request("alice", "general"), Result{Scope: Channel, RetryAfter: 30 * time.Second}},
+				{0, request("alice", "random"), allowed},
+				{0, request("alice", "memes"), allowed},
+				{0, request("bob", "memes"), Result{Scope: Guild, RetryAfter: time.Minute}},
+			},
+		},
+		{
+			name: "direct messages have no guild or channel to charge",
+			steps: []step{
+				{0, Request{UserID: "alice", Cost: 1}, allowed},
+				{0, Request{UserID: "alice", Cost: 1}, allowed},
+				{0, Request{UserID: "alice", Cost: 1}, allowed},
+				{0, Request{UserID: "alice", Cost: 1}, Result{Scope: User, RetryAfter: time.Minute}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, c := newTestLimiter(limits)
+			runSteps(t, l, c, tt.steps)
+		})
+	}
+}
+
+func TestSnapshotRestore(t *testing.T) {
+	limits := map[Scope]Limit{User: {Max: 2, Window: time.Minute}}
+	l, c := newTestLimiter(limits)
+
+	alice := Request{UserID: "alice", Cost: 1}
+	l.Allow(alice)
+	c.advance(30 * time.Second)
+	l.Allow(alice)
+
+	snapshot := l.Snapshot()
+	want := map[string][]int64{"user:alice": {c.now.Unix() + 30, c.now.Unix() + 60}}
+	if !maps.EqualFunc(snapshot, want, slices.Equal[[]int64]) {
+		t.Fatalf("Snapshot() = %v, want %v", snapshot, want)
+	}
+
+	restored, rc := newTestLimiter(limits)
+	rc.now = c.now.Add(45 * time.Second)
+	restored.Restore(snapshot)
+
+	// The first unit expired in the meantime
+	if got := restored.Allow(alice); !got.Allowed {
+		t.Errorf("Allow after restore = %+v, want allowed", got)
+	}
+	if got := restored.Allow(alice); got != (Result{Scope: User, RetryAfter: 15 * time.Second}) {
+		t.Errorf("Allow after restore = %+v, want denied for 15s", got)
+	}
+}
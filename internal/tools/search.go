@@ -0,0 +1,85 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/charmbracelet/log"
+	"wherd.dev/chad/internal/openrouter"
+	"wherd.dev/chad/internal/webpage"
+	"wherd.dev/chad/internal/websearch"
+)
+
+// Search looks up current information on the web, and reads the pages of the top results.
+type Search struct {
+	Provider websearch.Provider
+	Options  websearch.Options
+	Fetcher  *webpage.Fetcher // Only snippets are returned when nil
+	Pages    int              // Top results to read
+}
+
+func NewSearch(provider websearch.Provider, options websearch.Options, fetcher *webpage.Fetcher, pages int) *Search {
+	return &Search{Provider: provider, Options: options, Fetcher: fetcher, Pages: pages}
+}
+
+func (s *Search) Name() string {
+	return "search"
+}
+
+func (s *Search) Description() string {
+	return "Search the internet for information"
+}
+
+func (s *Search) Parameters() *openrouter.Schema {
+	return openrouter.Object().
+		RequiredProperty("query", openrouter.String("What to search for, phrased as a web search query"))
+}
+
+func (s *Search) Execute(ctx context.Context, args json.RawMessage) (string, error) {
+	var params struct {
+		Query string `json:"query"`
+	}
+
+	if err := json.Unmarshal(args, &params); err != nil {
+		return "", err
+	}
+
+	results, err := s.Provider.Search(ctx, params.Query, s.Options)
+	if err != nil {
+		return "", err
+	}
+
+	if s.Fetcher != nil {
+		FetchPages(ctx, s.Fetcher, results, s.Pages)
+	}
+
+	content, err := json.Marshal(results)
+	if err != nil {
+		return "", err
+	}
+
+	return string(content), nil
+}
+
+// FetchPages fills in the content of the top results at the same time. Pages that can't be
+// read keep only their snippets.
+func FetchPages(ctx context.Context, fetcher *webpage.Fetcher, results []*websearch.WebResult, pages int) {
+	wg := sync.WaitGroup{}
+	for _, result := range results[:min(len(results), pages)] {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			page, err := fetcher.Fetch(ctx, result.URL)
+			if err != nil {
+				log.Debugf("Failed to fetch %s: %v", result.URL, err)
+				return
+			}
+
+			result.Content = page.Text
+		}()
+	}
+
+	wg.Wait()
+}
@@ -0,0 +1,94 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"wherd.dev/chad/internal/webpage"
+	"wherd.dev/chad/internal/websearch"
+)
+
+// results is a provider that always finds the same pages.
+type results []*websearch.WebResult
+
+func (r results) Name() string {
+	return "test"
+}
+
+func (r results) Search(ctx context.Context, query string, options websearch.Options) ([]*websearch.WebResult, error) {
+	found := make([]*websearch.WebResult, len(r))
+	for i, result := range r {
+		copied := *result
+		found[i] = &copied
+	}
+	return found, nil
+}
+
+func TestSearchFetchesPages(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			fmt.Fprint(w, "User-agent: *\nDisallow: /private\n")
+		case "/go", "/rust", "/private":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			fmt.Fprintf(w, "<html><head><title>Page</title><script>var x = 1;</script></head><body><p>The full text of %s.</p></body></html>", r.URL.Path)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	fetcher := webpage.New(webpage.Options{MaxBytes: 1 << 20, Timeout: 5 * time.Second, CacheTTL: time.Minute})
+	fetcher.Client = server.Client()
+
+	provider := results{
+		{Title: "Go", URL: server.URL + "/go", Description: "Snippet of go"},
+		{Title: "Private", URL: server.URL + "/private", Description: "Snippet of private"},
+		{Title: "Rust", URL: server.URL + "/rust", Description: "Snippet of rust"},
+	}
+	search := NewSearch(provider, websearch.Options{}, fetcher, 2)
+
+	output, err := search.Execute(t.Context(), json.RawMessage(`{"query":"languages"}`))
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	var got []*websearch.WebResult
+	if err := json.Unmarshal([]byte(output), &got); err != nil {
+		t.Fatalf("failed to decode the tool's output %s: %v", output, err)
+	}
+
+	want := []string{
+		"The full text of /go.",
+		"", // Disallowed by robots.txt
+		"", // Past the pages to read
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d results, want %d", len(got), len(want))
+	}
+	for i, result := range got {
+		if result.Content != want[i] {
+			t.Errorf("content of %s = %q, want %q", result.URL, result.Content, want[i])
+		}
+		if result.Description != provider[i].Description {
+			t.Errorf("description of %s = %q, want the snippet kept", result.URL, result.Description)
+		}
+	}
+}
+
+func TestSearchWithoutFetcher(t *testing.T) {
+	search := NewSearch(results{{Title: "Go", URL: "https://go.dev"}}, websearch.Options{}, nil, 3)
+
+	output, err := search.Execute(t.Context(), json.RawMessage(`{"query":"golang"}`))
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if want := `[{"title":"Go","url":"https://go.dev","description":""}]`; output != want {
+		t.Errorf("output = %s, want %s", output, want)
+	}
+}
@@ -0,0 +1,100 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"wherd.dev/chad/internal/openrouter"
+)
+
+// Tool is a function the model can call while answering.
+type Tool interface {
+	Name() string
+	Description() string
+	Parameters() *openrouter.Schema
+	Execute(ctx context.Context, args json.RawMessage) (string, error)
+}
+
+type Registry struct {
+	tools []Tool
+	index map[string]Tool
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		tools: []Tool{},
+		index: map[string]Tool{},
+	}
+}
+
+// Register adds a tool to the registry, replacing any tool with the same name.
+func (r *Registry) Register(tool Tool) {
+	if _, ok := r.index[tool.Name()]; ok {
+		for i, t := range r.tools {
+			if t.Name() == tool.Name() {
+				r.tools[i] = tool
+			}
+		}
+	} else {
+		r.tools = append(r.tools, tool)
+	}
+
+	r.index[tool.Name()] = tool
+}
+
+func (r *Registry) Get(name string) (Tool, bool) {
+	tool, ok := r.index[name]
+	return tool, ok
+}
+
+func (r *Registry) Len() int {
+	return len(r.tools)
+}
+
+// Definitions returns the tools in the format expected by the chat completions API.
+func (r *Registry) Definitions() []openrouter.Tool {
+	definitions := make([]openrouter.Tool, len(r.tools))
+	for i, tool := range r.tools {
+		definitions[i] = openrouter.Tool{
+			Type: "function",
+			Function: openrouter.Function{
+				Name:        tool.Name(),
+				Description: tool.Description(),
+				Parameters:  tool.Parameters(),
+			},
+		}
+	}
+
+	return definitions
+}
+
+// Execute runs the tool call and returns the message to send back to the model.
+// Failures are reported to the model so it can recover or explain them.
+func (r *Registry) Execute(ctx context.Context, call *openrouter.ToolCall) *openrouter.Message {
+	message := &openrouter.Message{
+		Role:       "tool",
+		ToolCallID: call.ID,
+		Name:       call.Function.Name,
+	}
+
+	tool, ok := r.index[call.Function.Name]
+	if !ok {
+		message.Content = fmt.Sprintf("error: unknown function %s", call.Function.Name)
+		return message
+	}
+
+	args := json.RawMessage(call.Function.Arguments)
+	if len(args) == 0 {
+		args = json.RawMessage("{}")
+	}
+
+	content, err := tool.Execute(ctx, args)
+	if err != nil {
+		message.Content = fmt.Sprintf("error: %v", err)
+		return message
+	}
+
+	message.Content = content
+	return message
+}
@@ -0,0 +1,211 @@
+package webpage
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// robotsRules are the Allow and Disallow lines of robots.txt that apply to us.
+type robotsRules struct {
+	rules []robotsRule
+	all   bool // Everything is disallowed, because robots.txt could not be read
+}
+
+type robotsRule struct {
+	allow   bool
+	pattern string
+}
+
+// robotsRetry is how long a robots.txt that failed to load keeps a site off limits.
+const robotsRetry = 5 * time.Minute
+
+// allowed reports whether robots.txt lets us fetch the URL, reading it once per site and cache period.
+func (f *Fetcher) allowed(ctx context.Context, u *url.URL) (bool, error) {
+	site := u.Scheme + "://" + u.Host
+
+	f.mutex.Lock()
+	cached, ok := f.robots[site]
+	f.mutex.Unlock()
+
+	if !ok || time.Now().After(cached.expires) {
+		rules, err := f.readRobots(ctx, site)
+		if err != nil {
+			return false, err
+		}
+
+		ttl := f.Options.CacheTTL
+		if rules.all {
+			ttl = min(ttl, robotsRetry)
+		}
+		cached = &cachedRobots{rules: rules, expires: time.Now().Add(ttl)}
+
+		f.mutex.Lock()
+		f.robots[site] = cached
+		f.mutex.Unlock()
+	}
+
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+
+	return cached.rules.allows(path), nil
+}
+
+// readRobots downloads and parses robots.txt. A missing one allows everything, as RFC 9309
+// says, and a server error disallows everything until it is read again. Failing to reach
+// the site at all is an error, the page couldn't be fetched either.
+func (f *Fetcher) readRobots(ctx context.Context, site string) (*robotsRules, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", site+"/robots.txt", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", f.userAgent())
+
+	resp, err := f.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 500:
+		return &robotsRules{all: true}, nil
+	case resp.StatusCode >= 400:
+		return &robotsRules{}, nil
+	}
+
+	// Only the first 500 KiB have to be read
+	var body strings.Builder
+	buffer := make([]byte, 32*1024)
+	for body.Len() < 500*1024 {
+		n, err := resp.Body.Read(buffer)
+		body.Write(buffer[:n])
+		if err != nil {
+			break
+		}
+	}
+
+	return parseRobots(body.String(), f.agent()), nil
+}
+
+// parseRobots returns the rules of the groups for our agent, or the "*" groups if there are none.
+func parseRobots(text string, agent string) *robotsRules {
+	agent = strings.ToLower(agent)
+
+	ours, anyone := []robotsRule{}, []robotsRule{}
+	matched := false
+	forUs, forAnyone := false, false
+	inAgents := false
+
+	for _, line := range strings.Split(text, "\n") {
+		line, _, _ = strings.Cut(line, "#")
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			if !inAgents {
+				// A new group starts
+				forUs, forAnyone = false, false
+				inAgents = true
+			}
+
+			name := strings.ToLower(value)
+			if name == "*" {
+				forAnyone = true
+			} else if name != "" && strings.Contains(agent, name) {
+				forUs = true
+				matched = true
+			}
+		case "allow", "disallow":
+			inAgents = false
+			if value == "" {
+				continue
+			}
+
+			rule := robotsRule{allow: key == "allow", pattern: value}
+			if forUs {
+				ours = append(ours, rule)
+			}
+			if forAnyone {
+				anyone = append(anyone, rule)
+			}
+		default:
+			inAgents = false
+		}
+	}
+
+	if matched {
+		return &robotsRules{rules: ours}
+	}
+	return &robotsRules{rules: anyone}
+}
+
+// allows applies the longest matching rule to the path, Allow wins a tie.
+func (r *robotsRules) allows(path string) bool {
+	if r.all {
+		return false
+	}
+
+	allow, longest := true, -1
+	for _, rule := range r.rules {
+		if !matchRobots(rule.pattern, path) {
+			continue
+		}
+
+		if len(rule.pattern) > longest || (len(rule.pattern) == longest && rule.allow) {
+			allow, longest = rule.allow, len(rule.pattern)
+		}
+	}
+
+	return allow
+}
+
+// matchRobots matches a path against a pattern, where "*" is any run of characters and "$" ends the path.
+// Patterns come from any site, so it backtracks only to the last "*" and never takes more than
+// len(pattern) * len(path) steps.
+func matchRobots(pattern string, path string) bool {
+	// Without "$" the pattern only has to match the start of the path
+	if strings.HasSuffix(pattern, "$") {
+		pattern = pattern[:len(pattern)-1]
+	} else {
+		pattern += "*"
+	}
+
+	p, s := 0, 0
+	star, resume := -1, 0
+	for s < len(path) {
+		switch {
+		case p < len(pattern) && pattern[p] == '*':
+			star, resume = p, s
+			p++
+		case p < len(pattern) && pattern[p] == path[s]:
+			p++
+			s++
+		case star >= 0:
+			// Let the last "*" take one more character
+			p = star + 1
+			resume++
+			s = resume
+		default:
+			return false
+		}
+	}
+
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+
+	return p == len(pattern)
+}
@@ -0,0 +1,103 @@
+package webpage
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMatchRobots(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"/", "/", true},
+		{"/", "/anything", true},
+		{"/fish", "/fish.html", true},
+		{"/fish", "/Fish.html", false},
+		{"/fish", "/catfish", false},
+		{"/fish/", "/fish", false},
+		{"/*.php", "/index.php", true},
+		{"/*.php", "/dir/index.php?a=b", true},
+		{"/*.php", "/index.html", false},
+		{"/*.php$", "/index.php", true},
+		{"/*.php$", "/index.php?a=b", false},
+		{"/fish*", "/fish", true},
+		{"/fish$", "/fish", true},
+		{"/fish$", "/fish/", false},
+		{"/a*b*c", "/aXbYc", true},
+		{"/a*b*c", "/aXcYb", false},
+		{"/a*b*c$", "/abcc", true},
+		{"/a*b*c$", "/abcd", false},
+		{"*", "/anything", true},
+		{"/$", "/", true},
+		{"/$", "/a", false},
+	}
+
+	for _, tt := range tests {
+		if got := matchRobots(tt.pattern, tt.path); got != tt.want {
+			t.Errorf("matchRobots(%q, %q) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestMatchRobotsManyStars(t *testing.T) {
+	// Backtracking into every "*" would take exponential time on this
+	pattern := "/" + strings.Repeat("a*", 100) + "b$"
+	path := "/" + strings.Repeat("a", 5000)
+
+	start := time.Now()
+	if matchRobots(pattern, path) {
+		t.Errorf("matchRobots matched a path without a b")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("matchRobots took %v", elapsed)
+	}
+}
+
+func TestRobotsAllows(t *testing.T) {
+	rules := parseRobots(`
+User-agent: *
+Disallow: /private
+Allow: /private/public
+
+User-agent: ChadBot
+Disallow: /bots-only
+`, "Mozilla/5.0 (compatible; ChadBot/1.0)")
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/", true},
+		{"/bots-only/page", false},
+		{"/private", true}, // Only the group for the bot applies
+	}
+
+	for _, tt := range tests {
+		if got := rules.allows(tt.path); got != tt.want {
+			t.Errorf("allows(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+
+	rules = parseRobots(`
+User-agent: *
+Disallow: /private
+Allow: /private/public
+`, "ChadBot")
+
+	tests = []struct {
+		path string
+		want bool
+	}{
+		{"/private/secret", false},
+		{"/private/public/page", true}, // The longest match wins
+	}
+
+	for _, tt := range tests {
+		if got := rules.allows(tt.path); got != tt.want {
+			t.Errorf("allows(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package webpage
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+// skippedTags hold no readable text, or only page chrome like menus.
+var skippedTags = map[string]bool{
+	"script": true, "style": true, "noscript": true, "template": true, "svg": true, "iframe": true,
+	"nav": true, "header": true, "footer": true, "aside": true, "form": true, "button": true, "select": true,
+}
+
+// rawTags contain text that isn't markup, it runs until their closing tag.
+var rawTags = map[string]bool{"script": true, "style": true, "textarea": true}
+
+// blockTags start a new line.
+var blockTags = map[string]bool{
+	"p": true, "div": true, "br": true, "li": true, "tr": true, "hr": true, "section": true, "article": true,
+	"main": true, "blockquote": true, "pre": true, "table": true, "ul": true, "ol": true, "dl": true, "dt": true,
+	"dd": true, "figcaption": true, "h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+}
+
+// minMainText is how much text the article or main element of a page needs for the rest to be dropped.
+const minMainText = 200
+
+// extractText returns the title and readable text of an HTML page. It prefers the article or
+// main element, and leaves out scripts, styles, navigation and other page chrome.
+func extractText(page string) (string, string) {
+	var all, main, title strings.Builder
+	skipping := map[string]int{}
+	inMain, inTitle := 0, false
+
+	write := func(text string) {
+		if inTitle {
+			title.WriteString(text)
+			return
+		}
+
+		for _, depth := range skipping {
+			if depth > 0 {
+				return
+			}
+		}
+
+		all.WriteString(text)
+		if inMain > 0 {
+			main.WriteString(text)
+		}
+	}
+
+	for len(page) > 0 {
+		start := strings.IndexByte(page, '<')
+		if start < 0 {
+			write(html.UnescapeString(page))
+			break
+		}
+
+		write(html.UnescapeString(page[:start]))
+		page = page[start:]
+
+		if strings.HasPrefix(page, "<!--") {
+			end := strings.Index(page, "-->")
+			if end < 0 {
+				break
+			}
+			page = page[end+3:]
+			continue
+		}
+
+		if len(page) < 2 || !(unicode.IsLetter(rune(page[1])) || strings.ContainsRune("/!?", rune(page[1]))) {
+			// A literal "<" in the text
+			write("<")
+			page = page[1:]
+			continue
+		}
+
+		end := tagEnd(page)
+		if end < 0 {
+			break
+		}
+
+		tag := page[1:end]
+		page = page[end+1:]
+
+		closing := strings.HasPrefix(tag, "/")
+		name := strings.ToLower(strings.TrimLeft(tag, "/"))
+		if i := strings.IndexFunc(name, func(r rune) bool { return unicode.IsSpace(r) || r == '/' }); i >= 0 {
+			name = name[:i]
+		}
+		selfClosing := strings.HasSuffix(tag, "/")
+
+		if name == "" || name[0] == '!' || name[0] == '?' {
+			continue
+		}
+
+		if blockTags[name] {
+			write("\n")
+		}
+
+		switch {
+		case name == "title":
+			inTitle = !closing && !selfClosing
+		case name == "article" || name == "main":
+			if closing {
+				inMain = max(inMain-1, 0)
+			} else if !selfClosing {
+				inMain++
+			}
+		case skippedTags[name]:
+			if closing {
+				skipping[name] = max(skipping[name]-1, 0)
+			} else if !selfClosing {
+				skipping[name]++
+			}
+		}
+
+		if rawTags[name] && !closing && !selfClosing {
+			// Skip to the closing tag, the content is never markup
+			end := indexClosing(page, name)
+			if end < 0 {
+				break
+			}
+			if name == "textarea" {
+				write(html.UnescapeString(page[:end]))
+			}
+			page = page[end:]
+		}
+	}
+
+	text := normalize(main.String())
+	if len(text) < minMainText {
+		text = normalize(all.String())
+	}
+
+	return normalize(title.String()), text
+}
+
+// indexClosing returns the index of the closing tag of the element in s, in any case, or -1.
+func indexClosing(s string, name string) int {
+	for i := 0; ; {
+		j := strings.Index(s[i:], "</")
+		if j < 0 {
+			return -1
+		}
+
+		i += j
+		if end := i + 2 + len(name); end <= len(s) && strings.EqualFold(s[i+2:end], name) {
+			return i
+		}
+		i += 2
+	}
+}
+
+// tagEnd returns the index of the ">" that ends the tag at the start of s, skipping quoted attribute values.
+func tagEnd(s string) int {
+	quote := byte(0)
+	for i := 1; i < len(s); i++ {
+		switch {
+		case quote != 0:
+			if s[i] == quote {
+				quote = 0
+			}
+		case s[i] == '"' || s[i] == '\'':
+			quote = s[i]
+		case s[i] == '>':
+			return i
+		}
+	}
+
+	return -1
+}
+
+// normalize collapses whitespace within lines and drops empty lines.
+func normalize(text string) string {
+	lines := []string{}
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,87 @@
+package webpage
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExtractText(t *testing.T) {
+	tests := []struct {
+		name  string
+		page  string
+		title string
+		text  string
+	}{
+		{
+			name:  "title and paragraphs",
+			page:  "<html><head><title> Go &amp; more </title></head><body><p>First</p><p>Second  line</p></body></html>",
+			title: "Go & more",
+			text:  "First\nSecond line",
+		},
+		{
+			name: "scripts and styles in any case",
+			page: "<p>Before</p><SCRIPT>if (a < b) { document.write('<p>no</p>') }</Script><style>p { color: red }</STYLE><p>After</p>",
+			text: "Before\nAfter",
+		},
+		{
+			name: "closing tags of other elements inside a script",
+			page: "<p>Before</p><script>var s = '</scripts></div>';</script><p>After</p>",
+			text: "Before\nAfter",
+		},
+		{
+			name: "text areas keep their text",
+			page: "<p>Before</p><textarea>a <b>literal</b> &lt;tag&gt;</TEXTAREA><p>After</p>",
+			text: "Before\na <b>literal</b> <tag>\nAfter",
+		},
+		{
+			name: "unclosed script",
+			page: "<p>Before</p><script>var a = 1;",
+			text: "Before",
+		},
+		{
+			name: "page chrome",
+			page: "<nav><a>Home</a></nav><p>Content</p><footer>Copyright</footer>",
+			text: "Content",
+		},
+		{
+			name: "literal less than",
+			page: "<p>1 < 2</p><!-- <p>hidden</p> -->",
+			text: "1 < 2",
+		},
+		{
+			name: "main content wins",
+			page: "<div>Sidebar</div><article><p>" + strings.Repeat("word ", 50) + "</p></article>",
+			text: strings.TrimSpace(strings.Repeat("word ", 50)),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			title, text := extractText(tt.page)
+			if title != tt.title {
+				t.Errorf("title = %q, want %q", title, tt.title)
+			}
+			if text != tt.text {
+				t.Errorf("text = %q, want %q", text, tt.text)
+			}
+		})
+	}
+}
+
+func TestExtractTextManyRawTags(t *testing.T) {
+	// Looking for each closing tag must not go over the rest of the page again
+	tests := []string{
+		strings.Repeat("<script></script>", 1<<20/17),
+		strings.Repeat("<STYLE>a</STYLE>", 1<<20/16),
+		"<p>Text</p>" + strings.Repeat("<textarea>", 1<<20/10),
+	}
+
+	for _, page := range tests {
+		start := time.Now()
+		extractText(page)
+		if elapsed := time.Since(start); elapsed > 2*time.Second {
+			t.Errorf("extracting a %d byte page starting with %.20q took %v", len(page), page, elapsed)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package webpage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// maxCachedPages bounds the page cache, the oldest pages go first.
+const maxCachedPages = 256
+
+// ErrDisallowed is returned for pages the site's robots.txt keeps us out of.
+var ErrDisallowed = errors.New("disallowed by robots.txt")
+
+type Page struct {
+	URL   string `json:"url"`
+	Title string `json:"title,omitempty"`
+	Text  string `json:"text"`
+}
+
+type Options struct {
+	Agent    string        // Product token robots.txt rules are matched against
+	MaxBytes int64         // Pages are cut off after this many bytes
+	MaxChars int           // Extracted text is trimmed to this many characters, 0 for no limit
+	Timeout  time.Duration // For a whole fetch, including robots.txt
+	CacheTTL time.Duration // How long fetched pages and robots.txt files are kept
+}
+
+// Fetcher downloads pages and extracts their readable text.
+type Fetcher struct {
+	Client  *http.Client // Refuses private addresses, replace it to fetch from them
+	Options Options
+
+	mutex  sync.Mutex
+	pages  map[string]*cachedPage
+	robots map[string]*cachedRobots
+}
+
+type cachedPage struct {
+	page    *Page
+	expires time.Time
+}
+
+type cachedRobots struct {
+	rules   *robotsRules
+	expires time.Time
+}
+
+func New(options Options) *Fetcher {
+	dialer := &net.Dialer{Timeout: 10 * time.Second, Control: publicOnly}
+
+	return &Fetcher{
+		Client: &http.Client{
+			Transport: &http.Transport{
+				Proxy:               http.ProxyFromEnvironment,
+				DialContext:         dialer.DialContext,
+				TLSHandshakeTimeout: 10 * time.Second,
+			},
+		},
+		Options: options,
+		pages:   map[string]*cachedPage{},
+		robots:  map[string]*cachedRobots{},
+	}
+}
+
+// publicOnly refuses connections to loopback, private and link-local addresses, so search
+// results can't make the bot reach into the network it runs in.
+func publicOnly(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsUnspecified() {
+		return fmt.Errorf("refusing to connect to %s", host)
+	}
+
+	return nil
+}
+
+// Fetch returns the readable text of a page, from the cache when it was fetched recently.
+func (f *Fetcher) Fetch(ctx context.Context, rawURL string) (*Page, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported scheme %q", u.Scheme)
+	}
+	u.Fragment = ""
+
+	key := u.String()
+	f.mutex.Lock()
+	cached, ok := f.pages[key]
+	f.mutex.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.page, nil
+	}
+
+	if f.Options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, f.Options.Timeout)
+		defer cancel()
+	}
+
+	allowed, err := f.allowed(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, ErrDisallowed
+	}
+
+	body, contentType, err := f.get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &Page{URL: key}
+	if contentType == "text/plain" {
+		page.Text = normalize(body)
+	} else {
+		page.Title, page.Text = extractText(body)
+	}
+	page.Text = trim(page.Text, f.Options.MaxChars)
+
+	f.mutex.Lock()
+	f.pages[key] = &cachedPage{page: page, expires: time.Now().Add(f.Options.CacheTTL)}
+	f.prune()
+	f.mutex.Unlock()
+
+	return page, nil
+}
+
+// get downloads a page up to the size limit and returns it with its media type.
+func (f *Fetcher) get(ctx context.Context, u string) (string, string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", u, nil)
+	if err != nil {
+		return "", "", err
+	}
+
+	req.Header.Set("User-Agent", f.userAgent())
+	req.Header.Set("Accept", "text/html, application/xhtml+xml, text/plain;q=0.9")
+
+	resp, err := f.Client.Do(req)
+	if err != nil {
+		return "", "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", "", fmt.Errorf("server returned status %d", resp.StatusCode)
+	}
+
+	contentType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	switch contentType {
+	case "text/html", "application/xhtml+xml", "text/plain", "":
+	default:
+		return "", "", fmt.Errorf("unsupported content type %q", contentType)
+	}
+
+	body := io.Reader(resp.Body)
+	if f.Options.MaxBytes > 0 {
+		body = io.LimitReader(resp.Body, f.Options.MaxBytes)
+	}
+
+	b, err := io.ReadAll(body)
+	if err != nil {
+		return "", "", err
+	}
+
+	return strings.ToValidUTF8(string(b), ""), contentType, nil
+}
+
+// prune drops expired pages, and the oldest ones while the cache is full. The caller must hold the lock.
+func (f *Fetcher) prune() {
+	now := time.Now()
+	for key, cached := range f.pages {
+		if now.After(cached.expires) {
+			delete(f.pages, key)
+		}
+	}
+
+	for len(f.pages) > maxCachedPages {
+		oldest := ""
+		for key, cached := range f.pages {
+			if oldest == "" || cached.expires.Before(f.pages[oldest].expires) {
+				oldest = key
+			}
+		}
+		delete(f.pages, oldest)
+	}
+}
+
+func (f *Fetcher) userAgent() string {
+	return fmt.Sprintf("Mozilla/5.0 (compatible; %s)", f.agent())
+}
+
+func (f *Fetcher) agent() string {
+	if f.Options.Agent == "" {
+		return "chad"
+	}
+	return f.Options.Agent
+}
+
+// trim cuts text to at most limit characters at a word boundary.
+func trim(text string, limit int) string {
+	runes := []rune(text)
+	if limit <= 0 || len(runes) <= limit {
+		return text
+	}
+
+	cut := string(runes[:limit])
+	if i := strings.LastIndexAny(cut, " \n"); i > limit/2 {
+		cut = cut[:i]
+	}
+
+	return strings.TrimSpace(cut) + "…"
+}
@@ -0,0 +1,74 @@
+package websearch
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const braveURL = "https://api.search.brave.com/res/v1/web/search"
+
+// Brave searches with the Brave Search API.
+type Brave struct {
+	APIKey  string
+	BaseURL string       // braveURL when empty
+	Client  *http.Client // http.DefaultClient when nil
+}
+
+type braveResponse struct {
+	Web *struct {
+		Results []*WebResult `json:"results"`
+	} `json:"web"`
+}
+
+func (b *Brave) Name() string {
+	return "brave"
+}
+
+func (b *Brave) Search(ctx context.Context, query string, options Options) ([]*WebResult, error) {
+	if b.APIKey == "" {
+		return nil, errors.New("brave search key not set")
+	}
+
+	params := url.Values{
+		"q":                {query},
+		"text_decorations": {"false"},
+		"result_filter":    {"web"},
+		"extra_snippets":   {"true"},
+		"safesearch":       {SafeSearchStrict},
+	}
+	if options.Count > 0 {
+		// Brave returns at most 20 results
+		params.Set("count", strconv.Itoa(min(options.Count, 20)))
+	}
+	if options.SafeSearch != "" {
+		params.Set("safesearch", options.SafeSearch)
+	}
+	if options.Locale != "" {
+		language, country, ok := strings.Cut(options.Locale, "-")
+		params.Set("search_lang", strings.ToLower(language))
+		if ok {
+			params.Set("country", strings.ToUpper(country))
+		}
+	}
+
+	base := b.BaseURL
+	if base == "" {
+		base = braveURL
+	}
+
+	var response braveResponse
+	header := http.Header{"X-Subscription-Token": {b.APIKey}}
+	if err := getJSON(ctx, b.Client, base+"?"+params.Encode(), header, &response); err != nil {
+		return nil, err
+	}
+
+	if response.Web == nil {
+		return []*WebResult{}, nil
+	}
+
+	return limit(response.Web.Results, options.Count), nil
+}
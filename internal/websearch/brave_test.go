@@ -0,0 +1,77 @@
+package websearch
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"testing"
+)
+
+func TestBrave(t *testing.T) {
+	var query url.Values
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if key := r.Header.Get("X-Subscription-Token"); key != "test-key" {
+			t.Errorf("subscription token = %q, want test-key", key)
+		}
+		query = r.URL.Query()
+
+		fmt.Fprint(w, `{"web":{"results":[
+			{"title":"The Go Programming Language","url":"https://go.dev","description":"Build simple software.","page_age":"2024-01-02T00:00:00","extra_snippets":["Download Go","Tour"]},
+			{"title":"Go (game)","url":"https://en.wikipedia.org/wiki/Go_(game)","description":"A board game."},
+			{"title":"Go on GitHub","url":"https://github.com/golang/go","description":"The Go repository."}
+		]}}`)
+	})
+	b := &Brave{APIKey: "test-key", BaseURL: server.URL, Client: server.Client()}
+
+	results, err := b.Search(t.Context(), "golang", Options{Count: 2, SafeSearch: SafeSearchModerate, Locale: "en-gb"})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+
+	for param, want := range map[string]string{"q": "golang", "count": "2", "safesearch": "moderate", "search_lang": "en", "country": "GB"} {
+		if got := query.Get(param); got != want {
+			t.Errorf("parameter %s = %q, want %q", param, got, want)
+		}
+	}
+
+	if want := []string{"https://go.dev", "https://en.wikipedia.org/wiki/Go_(game)"}; !slices.Equal(urls(results), want) {
+		t.Fatalf("results = %q, want %q", urls(results), want)
+	}
+	first := results[0]
+	if first.Title != "The Go Programming Language" || first.Description != "Build simple software." || first.PageAge != "2024-01-02T00:00:00" {
+		t.Errorf("first result = %+v", first)
+	}
+	if want := []string{"Download Go", "Tour"}; !slices.Equal(first.ExtraSnippets, want) {
+		t.Errorf("extra snippets = %q, want %q", first.ExtraSnippets, want)
+	}
+}
+
+func TestBraveNoResults(t *testing.T) {
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"query":{"original":"golang"}}`)
+	})
+	b := &Brave{APIKey: "test-key", BaseURL: server.URL, Client: server.Client()}
+
+	results, err := b.Search(t.Context(), "golang", Options{})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if results == nil || len(results) != 0 {
+		t.Errorf("results = %v, want an empty list", results)
+	}
+}
+
+func TestBraveErrors(t *testing.T) {
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+
+	if _, err := (&Brave{BaseURL: server.URL}).Search(t.Context(), "golang", Options{}); err == nil {
+		t.Errorf("Search without a key succeeded")
+	}
+	b := &Brave{APIKey: "test-key", BaseURL: server.URL, Client: server.Client()}
+	if _, err := b.Search(t.Context(), "golang", Options{}); err == nil {
+		t.Errorf("Search succeeded with status 429")
+	}
+}
@@ -0,0 +1,47 @@
+package websearch
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"wherd.dev/chad/internal/cache"
+)
+
+// Cached remembers the results of a provider, so the same search doesn't cost another request.
+type Cached struct {
+	Provider Provider
+	Cache    *cache.Cache[[]*WebResult]
+}
+
+func (c *Cached) Name() string {
+	return c.Provider.Name()
+}
+
+func (c *Cached) Search(ctx context.Context, query string, options Options) ([]*WebResult, error) {
+	key := fmt.Sprintf("%s|%d|%s|%s|%s", c.Provider.Name(), options.Count, options.SafeSearch, options.Locale,
+		strings.Join(strings.Fields(strings.ToLower(query)), " "))
+
+	if results, ok := c.Cache.Get(key); ok {
+		return copyResults(results), nil
+	}
+
+	results, err := c.Provider.Search(ctx, query, options)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Cache.Set(key, copyResults(results))
+	return results, nil
+}
+
+// copyResults copies results, callers fill in their content after searching.
+func copyResults(results []*WebResult) []*WebResult {
+	copies := make([]*WebResult, len(results))
+	for i, result := range results {
+		r := *result
+		copies[i] = &r
+	}
+
+	return copies
+}
@@ -0,0 +1,80 @@
+package websearch
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"wherd.dev/chad/internal/cache"
+)
+
+func TestCached(t *testing.T) {
+	provider := &fake{name: "test", results: []*WebResult{{Title: "Go", URL: "https://go.dev"}}}
+	c := &Cached{Provider: provider, Cache: cache.New[[]*WebResult](10, time.Hour)}
+
+	search := func(query string, options Options) []*WebResult {
+		t.Helper()
+		results, err := c.Search(t.Context(), query, options)
+		if err != nil {
+			t.Fatalf("Search(%q) failed: %v", query, err)
+		}
+		return results
+	}
+
+	first := search("Golang  tutorial", Options{Count: 5})
+	if provider.searches != 1 {
+		t.Fatalf("searched %d times, want 1", provider.searches)
+	}
+
+	// Callers add the page's content to the results they got
+	first[0].Content = "The page"
+
+	again := search(" golang TUTORIAL", Options{Count: 5})
+	if provider.searches != 1 {
+		t.Errorf("searched %d times, want the same query in other case and spacing to hit the cache", provider.searches)
+	}
+	if len(again) != 1 || again[0].URL != "https://go.dev" {
+		t.Errorf("cached results = %+v, want the provider's", again)
+	}
+	if again[0].Content != "" {
+		t.Errorf("the cached results were changed by the caller of an earlier search")
+	}
+
+	search("golang tutorial", Options{Count: 10})
+	search("golang tutorial", Options{Count: 5, SafeSearch: SafeSearchOff})
+	search("golang tutorial", Options{Count: 5, Locale: "de"})
+	if provider.searches != 4 {
+		t.Errorf("searched %d times, want other options to miss the cache", provider.searches)
+	}
+
+	if stats := c.Cache.Stats(); stats.Hits != 1 {
+		t.Errorf("cache hits = %d, want 1", stats.Hits)
+	}
+}
+
+func TestCachedErrors(t *testing.T) {
+	provider := &fake{name: "test", err: errors.New("status 503")}
+	c := &Cached{Provider: provider, Cache: cache.New[[]*WebResult](10, time.Hour)}
+
+	for range 2 {
+		if _, err := c.Search(t.Context(), "golang", Options{}); err == nil {
+			t.Fatalf("Search succeeded")
+		}
+	}
+	if provider.searches != 2 {
+		t.Errorf("searched %d times, want failures not to be cached", provider.searches)
+	}
+}
+
+func TestCachedProviders(t *testing.T) {
+	shared := cache.New[[]*WebResult](10, time.Hour)
+	a := &fake{name: "a", results: []*WebResult{{URL: "https://a.example"}}}
+	b := &fake{name: "b", results: []*WebResult{{URL: "https://b.example"}}}
+
+	(&Cached{Provider: a, Cache: shared}).Search(t.Context(), "golang", Options{})
+	results, _ := (&Cached{Provider: b, Cache: shared}).Search(t.Context(), "golang", Options{})
+
+	if b.searches != 1 || len(results) != 1 || results[0].URL != "https://b.example" {
+		t.Errorf("results = %+v, want the second provider's own", results)
+	}
+}
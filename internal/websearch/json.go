@@ -0,0 +1,101 @@
+package websearch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// JSON searches with any endpoint that answers a GET request with a JSON list of results.
+type JSON struct {
+	// Endpoint is the URL to search with. "{query}", "{count}", "{safesearch}" and "{locale}"
+	// are replaced by the escaped search options.
+	Endpoint string
+	Header   http.Header
+	Results  string       // Dotted path to the list of results, like "data.items", the response itself when empty
+	Fields   Fields       // Names of the fields in each result
+	Client   *http.Client // http.DefaultClient when nil
+}
+
+// Fields maps the fields of a result, the ones that are empty use the names of WebResult.
+type Fields struct {
+	Title       string
+	URL         string
+	Description string
+}
+
+func (j *JSON) Name() string {
+	return "json"
+}
+
+func (j *JSON) Search(ctx context.Context, query string, options Options) ([]*WebResult, error) {
+	if j.Endpoint == "" {
+		return nil, errors.New("search endpoint not set")
+	}
+
+	count := ""
+	if options.Count > 0 {
+		count = strconv.Itoa(options.Count)
+	}
+
+	endpoint := strings.NewReplacer(
+		"{query}", url.QueryEscape(query),
+		"{count}", count,
+		"{safesearch}", url.QueryEscape(options.SafeSearch),
+		"{locale}", url.QueryEscape(options.Locale),
+	).Replace(j.Endpoint)
+
+	var response any
+	if err := getJSON(ctx, j.Client, endpoint, j.Header, &response); err != nil {
+		return nil, err
+	}
+
+	list := response
+	if j.Results != "" {
+		for _, key := range strings.Split(j.Results, ".") {
+			object, ok := list.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("no results at %q", j.Results)
+			}
+			list = object[key]
+		}
+	}
+
+	items, ok := list.([]any)
+	if !ok {
+		return nil, fmt.Errorf("no list of results at %q", j.Results)
+	}
+
+	results := []*WebResult{}
+	for _, item := range items {
+		object, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+
+		result := &WebResult{
+			Title:       field(object, j.Fields.Title, "title"),
+			URL:         field(object, j.Fields.URL, "url"),
+			Description: field(object, j.Fields.Description, "description"),
+		}
+		if result.URL != "" {
+			results = append(results, result)
+		}
+	}
+
+	return limit(results, options.Count), nil
+}
+
+// field returns a string field of a result by its configured name, or its default one.
+func field(object map[string]any, name string, fallback string) string {
+	if name == "" {
+		name = fallback
+	}
+
+	value, _ := object[name].(string)
+	return value
+}
@@ -0,0 +1,109 @@
+package websearch
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		results string
+		fields  Fields
+		want    []*WebResult
+		fails   bool
+	}{
+		{
+			name: "list at the top with the default fields",
+			body: `[{"title":"Go","url":"https://go.dev","description":"Build simple software."}]`,
+			want: []*WebResult{{Title: "Go", URL: "https://go.dev", Description: "Build simple software."}},
+		},
+		{
+			name:    "dotted path and mapped fields",
+			body:    `{"data":{"items":[{"name":"Go","link":"https://go.dev","snippet":"Build simple software.","url":"https://ignored.example"}]}}`,
+			results: "data.items",
+			fields:  Fields{Title: "name", URL: "link", Description: "snippet"},
+			want:    []*WebResult{{Title: "Go", URL: "https://go.dev", Description: "Build simple software."}},
+		},
+		{
+			name:    "skips results without a URL or that aren't objects",
+			body:    `{"items":[{"title":"No link"},"text",{"title":"Go","url":"https://go.dev","description":42}]}`,
+			results: "items",
+			want:    []*WebResult{{Title: "Go", URL: "https://go.dev"}},
+		},
+		{
+			name:    "path through a value that isn't an object",
+			body:    `{"data":[{"url":"https://go.dev"}]}`,
+			results: "data.items",
+			fails:   true,
+		},
+		{
+			name:    "path to something that isn't a list",
+			body:    `{"data":{"items":{"url":"https://go.dev"}}}`,
+			results: "data.items",
+			fails:   true,
+		},
+		{
+			name:    "missing path",
+			body:    `{"data":{}}`,
+			results: "data.items",
+			fails:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, tt.body)
+			})
+			j := &JSON{Endpoint: server.URL, Results: tt.results, Fields: tt.fields, Client: server.Client()}
+
+			results, err := j.Search(t.Context(), "golang", Options{})
+			if tt.fails {
+				if err == nil {
+					t.Errorf("Search succeeded with %v", results)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Search failed: %v", err)
+			}
+
+			if !reflect.DeepEqual(results, tt.want) {
+				t.Errorf("results = %+v, want %+v", results, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONEndpoint(t *testing.T) {
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.RawQuery != "q=go+%26+rust&n=3&safe=off&hl=en-US" {
+			t.Errorf("query = %q", r.URL.RawQuery)
+		}
+		if key := r.Header.Get("Authorization"); key != "Bearer test-key" {
+			t.Errorf("authorization = %q, want the configured header", key)
+		}
+
+		fmt.Fprint(w, `[{"url":"https://a.example"},{"url":"https://b.example"},{"url":"https://c.example"},{"url":"https://d.example"}]`)
+	})
+	j := &JSON{
+		Endpoint: server.URL + "/?q={query}&n={count}&safe={safesearch}&hl={locale}",
+		Header:   http.Header{"Authorization": {"Bearer test-key"}},
+		Client:   server.Client(),
+	}
+
+	results, err := j.Search(t.Context(), "go & rust", Options{Count: 3, SafeSearch: SafeSearchOff, Locale: "en-US"})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+
+	// Endpoints may ignore the count
+	if want := []string{"https://a.example", "https://b.example", "https://c.example"}; !slices.Equal(urls(results), want) {
+		t.Errorf("results = %q, want %q", urls(results), want)
+	}
+}
@@ -0,0 +1,66 @@
+package websearch
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// SearXNG searches with a SearXNG instance, which needs the JSON format enabled in its settings.
+type SearXNG struct {
+	BaseURL string       // Like "https://searx.example.com"
+	Client  *http.Client // http.DefaultClient when nil
+}
+
+type searxngResponse struct {
+	Results []struct {
+		Title         string `json:"title"`
+		URL           string `json:"url"`
+		Content       string `json:"content"`
+		PublishedDate string `json:"publishedDate"`
+	} `json:"results"`
+}
+
+func (s *SearXNG) Name() string {
+	return "searxng"
+}
+
+func (s *SearXNG) Search(ctx context.Context, query string, options Options) ([]*WebResult, error) {
+	if s.BaseURL == "" {
+		return nil, errors.New("searxng URL not set")
+	}
+
+	params := url.Values{
+		"q":          {query},
+		"format":     {"json"},
+		"safesearch": {"2"},
+	}
+	switch options.SafeSearch {
+	case SafeSearchOff:
+		params.Set("safesearch", "0")
+	case SafeSearchModerate:
+		params.Set("safesearch", "1")
+	}
+	if options.Locale != "" {
+		params.Set("language", options.Locale)
+	}
+
+	var response searxngResponse
+	if err := getJSON(ctx, s.Client, strings.TrimSuffix(s.BaseURL, "/")+"/search?"+params.Encode(), nil, &response); err != nil {
+		return nil, err
+	}
+
+	results := make([]*WebResult, len(response.Results))
+	for i, result := range response.Results {
+		results[i] = &WebResult{
+			Title:       result.Title,
+			URL:         result.URL,
+			Description: result.Content,
+			PageAge:     result.PublishedDate,
+		}
+	}
+
+	return limit(results, options.Count), nil
+}
@@ -0,0 +1,83 @@
+package websearch
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestSearXNG(t *testing.T) {
+	tests := []struct {
+		safeSearch string
+		want       string
+	}{
+		{SafeSearchOff, "0"},
+		{SafeSearchModerate, "1"},
+		{SafeSearchStrict, "2"},
+		{"", "2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%q", tt.safeSearch), func(t *testing.T) {
+			server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/search" {
+					t.Errorf("path = %q, want /search", r.URL.Path)
+				}
+				query := r.URL.Query()
+				for param, want := range map[string]string{"q": "golang", "format": "json", "safesearch": tt.want, "language": "en-US"} {
+					if got := query.Get(param); got != want {
+						t.Errorf("parameter %s = %q, want %q", param, got, want)
+					}
+				}
+
+				fmt.Fprint(w, `{"query":"golang","results":[
+					{"title":"The Go Programming Language","url":"https://go.dev","content":"Build simple software.","publishedDate":"2024-01-02T00:00:00","engine":"duckduckgo"},
+					{"title":"Go on GitHub","url":"https://github.com/golang/go","content":"The Go repository.","publishedDate":null}
+				]}`)
+			})
+			s := &SearXNG{BaseURL: server.URL + "/", Client: server.Client()}
+
+			results, err := s.Search(t.Context(), "golang", Options{SafeSearch: tt.safeSearch, Locale: "en-US"})
+			if err != nil {
+				t.Fatalf("Search failed: %v", err)
+			}
+
+			want := []*WebResult{
+				{Title: "The Go Programming Language", URL: "https://go.dev", Description: "Build simple software.", PageAge: "2024-01-02T00:00:00"},
+				{Title: "Go on GitHub", URL: "https://github.com/golang/go", Description: "The Go repository."},
+			}
+			if !reflect.DeepEqual(results, want) {
+				t.Errorf("results = %+v, want %+v", results, want)
+			}
+		})
+	}
+}
+
+func TestSearXNGCount(t *testing.T) {
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"results":[{"url":"https://a.example"},{"url":"https://b.example"},{"url":"https://c.example"}]}`)
+	})
+	s := &SearXNG{BaseURL: server.URL, Client: server.Client()}
+
+	results, err := s.Search(t.Context(), "golang", Options{Count: 2})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if want := []string{"https://a.example", "https://b.example"}; !slices.Equal(urls(results), want) {
+		t.Errorf("results = %q, want %q", urls(results), want)
+	}
+}
+
+func TestSearXNGJSONDisabled(t *testing.T) {
+	// Instances without the JSON format answer 403
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	})
+	s := &SearXNG{BaseURL: server.URL, Client: server.Client()}
+
+	if _, err := s.Search(t.Context(), "golang", Options{}); err == nil {
+		t.Errorf("Search succeeded with status 403")
+	}
+}
@@ -3,57 +3,112 @@ package websearch
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
-	"net/url"
 	"time"
 )
 
-type SearchResult struct {
-	Web *WebResults `json:"web"`
+// SafeSearch levels, providers map them to their own.
+const (
+	SafeSearchOff      = "off"
+	SafeSearchModerate = "moderate"
+	SafeSearchStrict   = "strict"
+)
+
+// requestTimeout bounds a single search request.
+const requestTimeout = 30 * time.Second
+
+type WebResult struct {
+	Title         string   `json:"title"`
+	URL           string   `json:"url"`
+	Description   string   `json:"description"`
+	PageAge       string   `json:"page_age,omitempty"`
+	ExtraSnippets []string `json:"extra_snippets,omitempty"` // More excerpts from the page, Brave only
+	Content       string   `json:"content,omitempty"`        // Readable text of the page, when it was fetched
 }
 
-type WebResults struct {
-	Results []*WebResult `json:"results"`
+// Options tune a search, providers ignore what they don't support.
+type Options struct {
+	Count      int    // Results wanted, the provider's default when 0
+	SafeSearch string // SafeSearchOff, SafeSearchModerate or SafeSearchStrict
+	Locale     string // Language and optionally region, like "en" or "en-US"
 }
 
-type WebResult struct {
-	Title       string `json:"title"`
-	URL         string `json:"url"`
-	Description string `json:"description"`
-	PageAge     string `json:"page_age"`
+// Provider is a search engine.
+type Provider interface {
+	Name() string
+	Search(ctx context.Context, query string, options Options) ([]*WebResult, error)
 }
 
-func Search(apikey string, query string) ([]*WebResult, error) {
-	if apikey == "" {
-		return nil, fmt.Errorf("brave search key not set")
+// Failover searches with each provider in turn until one of them succeeds.
+type Failover []Provider
+
+func (f Failover) Name() string {
+	return "failover"
+}
+
+func (f Failover) Search(ctx context.Context, query string, options Options) ([]*WebResult, error) {
+	if len(f) == 0 {
+		return nil, errors.New("no search provider configured")
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	errs := []error{}
+	for _, provider := range f {
+		results, err := provider.Search(ctx, query, options)
+		if err == nil {
+			return results, nil
+		}
+
+		errs = append(errs, fmt.Errorf("%s: %w", provider.Name(), err))
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	return nil, errors.Join(errs...)
+}
+
+// getJSON fetches a URL and decodes the JSON response into v.
+func getJSON(ctx context.Context, client *http.Client, url string, header http.Header, v any) error {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
 	defer cancel()
 
-	req, err := http.NewRequestWithContext(ctx, "GET", "https://api.search.brave.com/res/v1/web/search?count=7&safesearch=strict&text_decorations=false&result_filter=web&extra_snippets=true&q="+url.QueryEscape(query), nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	req.Header.Set("X-Subscription-Token", apikey)
-	req.Header.Set("Content-Type", "application/json")
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Accept", "application/json")
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := client.Do(req)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API returned status %d", resp.StatusCode)
+		return fmt.Errorf("API returned status %d", resp.StatusCode)
 	}
 
-	var results SearchResult
-	if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	return results.Web.Results, nil
+	return nil
+}
+
+// limit cuts results down to the wanted count, for providers that can't be asked for it.
+func limit(results []*WebResult, count int) []*WebResult {
+	if count > 0 && len(results) > count {
+		return results[:count]
+	}
+	return results
 }
@@ -0,0 +1,139 @@
+package websearch
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+// newTestServer starts a server for the handler, closed when the test ends.
+func newTestServer(t *testing.T, handler http.HandlerFunc) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// fake is a provider that answers with fixed results or an error, counting its searches.
+type fake struct {
+	name     string
+	results  []*WebResult
+	err      error
+	searches int
+	called   *[]string // Names of the providers searched with, in order
+}
+
+func (f *fake) Name() string {
+	return f.name
+}
+
+func (f *fake) Search(ctx context.Context, query string, options Options) ([]*WebResult, error) {
+	f.searches++
+	if f.called != nil {
+		*f.called = append(*f.called, f.name)
+	}
+	return f.results, f.err
+}
+
+func urls(results []*WebResult) []string {
+	list := make([]string, len(results))
+	for i, result := range results {
+		list[i] = result.URL
+	}
+	return list
+}
+
+func TestFailover(t *testing.T) {
+	results := []*WebResult{{Title: "Go", URL: "https://go.dev"}}
+	down := errors.New("status 503")
+
+	tests := []struct {
+		name      string
+		providers []*fake
+		called    []string
+		results   []string
+		errs      []error
+	}{
+		{
+			name:      "the first provider answers",
+			providers: []*fake{{name: "a", results: results}, {name: "b", err: down}},
+			called:    []string{"a"},
+			results:   []string{"https://go.dev"},
+		},
+		{
+			name:      "falls over to the next provider in order",
+			providers: []*fake{{name: "a", err: down}, {name: "b", err: down}, {name: "c", results: results}, {name: "d", results: results}},
+			called:    []string{"a", "b", "c"},
+			results:   []string{"https://go.dev"},
+		},
+		{
+			name:      "no results is an answer",
+			providers: []*fake{{name: "a", results: []*WebResult{}}, {name: "b", results: results}},
+			called:    []string{"a"},
+			results:   []string{},
+		},
+		{
+			name:      "every provider fails",
+			providers: []*fake{{name: "a", err: down}, {name: "b", err: context.DeadlineExceeded}},
+			called:    []string{"a", "b"},
+			errs:      []error{down, context.DeadlineExceeded},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := []string{}
+			failover := Failover{}
+			for _, provider := range tt.providers {
+				provider.called = &called
+				failover = append(failover, provider)
+			}
+
+			got, err := failover.Search(t.Context(), "golang", Options{})
+			if !slices.Equal(called, tt.called) {
+				t.Errorf("searched with %q, want %q", called, tt.called)
+			}
+
+			if tt.errs == nil {
+				if err != nil {
+					t.Fatalf("Search failed: %v", err)
+				}
+				if !slices.Equal(urls(got), tt.results) {
+					t.Errorf("results = %q, want %q", urls(got), tt.results)
+				}
+				return
+			}
+
+			for _, want := range tt.errs {
+				if !errors.Is(err, want) {
+					t.Errorf("err = %v, want it to include %v", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestFailoverCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	first := &fake{name: "a", err: context.Canceled}
+	second := &fake{name: "b", results: []*WebResult{{URL: "https://go.dev"}}}
+
+	if _, err := (Failover{first, second}).Search(ctx, "golang", Options{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+	if second.searches != 0 {
+		t.Errorf("searched with the next provider after the search was cancelled")
+	}
+}
+
+func TestFailoverEmpty(t *testing.T) {
+	if _, err := (Failover{}).Search(t.Context(), "golang", Options{}); err == nil {
+		t.Errorf("Search without providers succeeded")
+	}
+}
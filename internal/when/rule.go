@@ -0,0 +1,202 @@
+package when
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frequency is the unit a rule repeats in.
+type Frequency string
+
+const (
+	Minutely Frequency = "MINUTELY"
+	Hourly   Frequency = "HOURLY"
+	Daily    Frequency = "DAILY"
+	Weekly   Frequency = "WEEKLY"
+	Monthly  Frequency = "MONTHLY"
+)
+
+// Rule is the subset of an iCalendar RRULE reminders need: FREQ, INTERVAL, BYDAY and BYMONTHDAY.
+// Occurrences keep the wall clock time of the first one, so they survive DST changes.
+type Rule struct {
+	Freq       Frequency
+	Interval   int
+	ByDay      []time.Weekday // Weekly rules only, sorted from Monday
+	ByMonthDay int            // Monthly rules only, the last day of shorter months stands in for it
+}
+
+var dayCodes = []string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// ParseRule parses a rule written by Rule.String, like "FREQ=WEEKLY;INTERVAL=1;BYDAY=MO,TH"
+// or "FREQ=MONTHLY;INTERVAL=1;BYMONTHDAY=31".
+func ParseRule(s string) (*Rule, error) {
+	r := &Rule{Interval: 1}
+	for _, part := range strings.Split(s, ";") {
+		key, value, _ := strings.Cut(part, "=")
+		switch key {
+		case "FREQ":
+			r.Freq = Frequency(value)
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid interval %q", value)
+			}
+			r.Interval = n
+		case "BYDAY":
+			for _, code := range strings.Split(value, ",") {
+				day := slices.Index(dayCodes, code)
+				if day < 0 {
+					return nil, fmt.Errorf("invalid day %q", code)
+				}
+				r.ByDay = append(r.ByDay, time.Weekday(day))
+			}
+		case "BYMONTHDAY":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 || n > 31 {
+				return nil, fmt.Errorf("invalid day of the month %q", value)
+			}
+			r.ByMonthDay = n
+		default:
+			return nil, fmt.Errorf("unsupported rule part %q", part)
+		}
+	}
+
+	switch r.Freq {
+	case Minutely, Hourly, Daily, Weekly, Monthly:
+	default:
+		return nil, fmt.Errorf("unsupported frequency %q", r.Freq)
+	}
+
+	r.sortDays()
+	return r, nil
+}
+
+func (r *Rule) String() string {
+	s := fmt.Sprintf("FREQ=%s;INTERVAL=%d", r.Freq, r.Interval)
+	if len(r.ByDay) > 0 {
+		codes := make([]string, len(r.ByDay))
+		for i, day := range r.ByDay {
+			codes[i] = dayCodes[day]
+		}
+		s += ";BYDAY=" + strings.Join(codes, ",")
+	}
+	if r.ByMonthDay > 0 {
+		s += ";BYMONTHDAY=" + strconv.Itoa(r.ByMonthDay)
+	}
+
+	return s
+}
+
+// Period returns the shortest time between two occurrences, roughly for months.
+func (r *Rule) Period() time.Duration {
+	switch r.Freq {
+	case Minutely:
+		return time.Duration(r.Interval) * time.Minute
+	case Hourly:
+		return time.Duration(r.Interval) * time.Hour
+	case Daily:
+		return time.Duration(r.Interval) * 24 * time.Hour
+	case Monthly:
+		return time.Duration(r.Interval) * 28 * 24 * time.Hour
+	}
+
+	if len(r.ByDay) > 1 {
+		return 24 * time.Hour
+	}
+	return time.Duration(r.Interval) * 7 * 24 * time.Hour
+}
+
+// Next returns the first occurrence after the given one, in its location.
+func (r *Rule) Next(previous time.Time) time.Time {
+	switch r.Freq {
+	case Minutely:
+		return previous.Add(time.Duration(r.Interval) * time.Minute)
+	case Hourly:
+		return previous.Add(time.Duration(r.Interval) * time.Hour)
+	case Daily:
+		return previous.AddDate(0, 0, r.Interval)
+	case Monthly:
+		return r.nextMonth(previous)
+	}
+
+	if len(r.ByDay) == 0 {
+		return previous.AddDate(0, 0, 7*r.Interval)
+	}
+
+	next := previous
+	for {
+		next = next.AddDate(0, 0, 1)
+		if next.Weekday() == time.Monday {
+			// Skip the weeks in between
+			next = next.AddDate(0, 0, 7*(r.Interval-1))
+		}
+		if slices.Contains(r.ByDay, next.Weekday()) {
+			return next
+		}
+	}
+}
+
+// nextMonth returns the occurrence Interval months after the previous one. Counting from
+// the rule's day instead of the previous occurrence keeps a short month from moving it.
+func (r *Rule) nextMonth(previous time.Time) time.Time {
+	day := r.ByMonthDay
+	if day == 0 {
+		// Rules from before BYMONTHDAY
+		day = previous.Day()
+	}
+
+	month := time.Date(previous.Year(), previous.Month()+time.Month(r.Interval), 1,
+		previous.Hour(), previous.Minute(), previous.Second(), previous.Nanosecond(), previous.Location())
+	last := time.Date(month.Year(), month.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+
+	return month.AddDate(0, 0, min(day, last)-1)
+}
+
+// After returns the first occurrence after the given time, counting from a previous one.
+func (r *Rule) After(previous time.Time, after time.Time) time.Time {
+	next := r.Next(previous)
+	for !next.After(after) {
+		next = r.Next(next)
+	}
+
+	return next
+}
+
+// Describe returns the rule in words, like "every Monday and Thursday".
+func (r *Rule) Describe() string {
+	units := map[Frequency]string{Minutely: "minute", Hourly: "hour", Daily: "day", Weekly: "week", Monthly: "month"}
+	every := "every " + units[r.Freq]
+	if r.Interval > 1 {
+		every = fmt.Sprintf("every %d %ss", r.Interval, units[r.Freq])
+	}
+
+	if len(r.ByDay) == 0 {
+		return every
+	}
+
+	days := make([]string, len(r.ByDay))
+	for i, day := range r.ByDay {
+		days[i] = day.String()
+	}
+
+	list := strings.Join(days, " and ")
+	if len(days) > 2 {
+		list = strings.Join(days[:len(days)-1], ", ") + " and " + days[len(days)-1]
+	}
+
+	if r.Interval > 1 {
+		return every + " on " + list
+	}
+	return "every " + list
+}
+
+// sortDays orders the days from Monday, the way weeks are counted.
+func (r *Rule) sortDays() {
+	slices.SortFunc(r.ByDay, func(a, b time.Weekday) int {
+		return (int(a)+6)%7 - (int(b)+6)%7
+	})
+	r.ByDay = slices.Compact(r.ByDay)
+}
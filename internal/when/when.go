@@ -0,0 +1,556 @@
+package when
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultHour is when reminders for a day without a time of day go off.
+const defaultHour = 9
+
+// Result is a parsed time expression. Rule is nil when it happens only once.
+type Result struct {
+	Time time.Time
+	Rule *Rule
+}
+
+// Parse reads a time expression from the start of the words, relative to now and in its location.
+// It returns the result and how many words the expression used, the rest is left to the caller.
+//
+// Understood expressions include "5m", "1d12h", "in 2 weeks", "tomorrow at 9am", "friday 17:30",
+// "2026-11-01 14:00", "every monday 10:00", "every weekday at 8am", "every 2 hours" and "daily".
+func Parse(words []string, now time.Time) (*Result, int, error) {
+	p := &parser{now: now}
+	for _, word := range words {
+		p.words = append(p.words, strings.TrimRight(strings.ToLower(word), ","))
+	}
+
+	var result *Result
+	var err error
+	if p.accept("every") {
+		result, err = p.recurring()
+	} else if freq, ok := adverbs[p.peek()]; ok {
+		p.pos++
+		result, err = p.recurringAt(&Rule{Freq: freq, Interval: 1})
+	} else {
+		result, err = p.once()
+	}
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return result, p.pos, nil
+}
+
+const compactUnits = `months?|mo|weeks?|wks?|w|days?|d|hours?|hrs?|h|minutes?|mins?|m|seconds?|secs?|s`
+
+var (
+	compactRegex     = regexp.MustCompile(`^(\d+)(` + compactUnits + `)`)
+	compactWordRegex = regexp.MustCompile(`^(?:\d+(?:` + compactUnits + `))+$`)
+	clockRegex       = regexp.MustCompile(`^(\d{1,2})(?::(\d{2}))?(am|pm)?$`)
+	dateRegex        = regexp.MustCompile(`^(\d{4})-(\d{2})-(\d{2})$`)
+	isoRegex         = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2})t(\d{1,2}:\d{2})$`)
+	numberRegex      = regexp.MustCompile(`^\d+$`)
+
+	units = map[string]string{
+		"s": "s", "sec": "s", "secs": "s", "second": "s", "seconds": "s",
+		"m": "m", "min": "m", "mins": "m", "minute": "m", "minutes": "m",
+		"h": "h", "hr": "h", "hrs": "h", "hour": "h", "hours": "h",
+		"d": "d", "day": "d", "days": "d",
+		"w": "w", "wk": "w", "wks": "w", "week": "w", "weeks": "w",
+		"mo": "mo", "month": "mo", "months": "mo",
+	}
+
+	frequencies = map[string]Frequency{"m": Minutely, "h": Hourly, "d": Daily, "w": Weekly, "mo": Monthly}
+	adverbs     = map[string]Frequency{"hourly": Hourly, "daily": Daily, "weekly": Weekly, "monthly": Monthly}
+
+	weekdays = map[string]time.Weekday{
+		"sunday": time.Sunday, "sun": time.Sunday,
+		"monday": time.Monday, "mon": time.Monday,
+		"tuesday": time.Tuesday, "tue": time.Tuesday, "tues": time.Tuesday,
+		"wednesday": time.Wednesday, "wed": time.Wednesday,
+		"thursday": time.Thursday, "thu": time.Thursday, "thur": time.Thursday, "thurs": time.Thursday,
+		"friday": time.Friday, "fri": time.Friday,
+		"saturday": time.Saturday, "sat": time.Saturday,
+	}
+)
+
+type parser struct {
+	words []string
+	pos   int
+	now   time.Time
+}
+
+func (p *parser) peek() string {
+	if p.pos < len(p.words) {
+		return p.words[p.pos]
+	}
+
+	return ""
+}
+
+func (p *parser) accept(words ...string) bool {
+	for _, word := range words {
+		if p.peek() == word {
+			p.pos++
+			return true
+		}
+	}
+
+	return false
+}
+
+// offset is an amount of time to add, calendar units are kept apart so days stay days across DST.
+type offset struct {
+	months   int
+	days     int
+	duration time.Duration
+}
+
+// maxOffset bounds amounts of time, far beyond any reminder and far from overflowing.
+const maxOffset = 100 * 365 * 24 * time.Hour
+
+var errTooFar = fmt.Errorf("reminders can only be set up to %d years ahead", maxOffset/(365*24*time.Hour))
+
+// unitSizes are the longest each unit gets, to hold amounts against maxOffset.
+var unitSizes = map[string]time.Duration{
+	"mo": 31 * 24 * time.Hour, "w": 7 * 24 * time.Hour, "d": 24 * time.Hour,
+	"h": time.Hour, "m": time.Minute, "s": time.Second,
+}
+
+// add adds an amount written as digits, failing when it goes past maxOffset.
+func (o offset) add(unit string, amount string) (offset, error) {
+	n, err := strconv.Atoi(amount)
+	if err != nil || n > int(maxOffset/unitSizes[unit]) {
+		return offset{}, errTooFar
+	}
+
+	switch unit {
+	case "mo":
+		o.months += n
+	case "w":
+		o.days += 7 * n
+	case "d":
+		o.days += n
+	case "h":
+		o.duration += time.Duration(n) * time.Hour
+	case "m":
+		o.duration += time.Duration(n) * time.Minute
+	case "s":
+		o.duration += time.Duration(n) * time.Second
+	}
+
+	if o.longest() > maxOffset {
+		return offset{}, errTooFar
+	}
+	return o, nil
+}
+
+// plus adds another offset, failing when the sum goes past maxOffset.
+func (o offset) plus(other offset) (offset, error) {
+	o.months, o.days, o.duration = o.months+other.months, o.days+other.days, o.duration+other.duration
+	if o.longest() > maxOffset {
+		return offset{}, errTooFar
+	}
+	return o, nil
+}
+
+// longest returns how long the offset is at most, with months of 31 days.
+func (o offset) longest() time.Duration {
+	return time.Duration(o.months)*unitSizes["mo"] + time.Duration(o.days)*unitSizes["d"] + o.duration
+}
+
+func (o offset) from(t time.Time) time.Time {
+	return t.AddDate(0, o.months, o.days).Add(o.duration)
+}
+
+func (o offset) isZero() bool {
+	return o.months == 0 && o.days == 0 && o.duration == 0
+}
+
+// once parses a one-off expression.
+func (p *parser) once() (*Result, error) {
+	start := p.pos
+	p.accept("in")
+	if o, ok, err := p.offset(); err != nil {
+		return nil, err
+	} else if ok {
+		return &Result{Time: o.from(p.now)}, nil
+	}
+
+	p.pos = start
+	t, ok := p.moment()
+	if !ok {
+		return nil, fmt.Errorf("I don't understand when %q is", p.peek())
+	} else if !t.After(p.now) {
+		return nil, fmt.Errorf("%s is in the past", t.Format("2006-01-02 15:04"))
+	}
+
+	return &Result{Time: t}, nil
+}
+
+// offset parses durations like "5m", "1h30m", "2 days" or "1 hour and 30 minutes".
+func (p *parser) offset() (offset, bool, error) {
+	o := offset{}
+	for {
+		ok, err := p.offsetPart(&o)
+		if err != nil {
+			return offset{}, false, err
+		} else if !ok {
+			break
+		}
+
+		if p.accept("and") {
+			if ok, err := p.offsetPart(&o); err != nil {
+				return offset{}, false, err
+			} else if !ok {
+				p.pos--
+				break
+			}
+		}
+	}
+
+	return o, !o.isZero(), nil
+}
+
+// offsetPart adds one amount like "1d12h" or "2 days" to the offset.
+func (p *parser) offsetPart(o *offset) (bool, error) {
+	word := p.peek()
+	compact, ok, err := parseCompact(word)
+	if err != nil {
+		return false, err
+	} else if ok {
+		if *o, err = o.plus(compact); err != nil {
+			return false, err
+		}
+		p.pos++
+		return true, nil
+	}
+
+	if unit := units[p.lookahead(1)]; numberRegex.MatchString(word) && unit != "" {
+		if *o, err = o.add(unit, word); err != nil {
+			return false, err
+		}
+		p.pos += 2
+		return true, nil
+	}
+
+	return false, nil
+}
+
+func (p *parser) lookahead(n int) string {
+	if p.pos+n < len(p.words) {
+		return p.words[p.pos+n]
+	}
+
+	return ""
+}
+
+// parseCompact parses a word made only of amounts and units, like "1d12h".
+func parseCompact(word string) (offset, bool, error) {
+	o := offset{}
+	if word == "" || !compactWordRegex.MatchString(word) {
+		return o, false, nil
+	}
+
+	for word != "" {
+		match := compactRegex.FindStringSubmatch(word)
+		if match == nil {
+			return offset{}, false, nil
+		}
+
+		var err error
+		if o, err = o.add(units[match[2]], match[1]); err != nil {
+			return offset{}, false, err
+		}
+		word = word[len(match[0]):]
+	}
+
+	return o, true, nil
+}
+
+// moment parses a day, a time of day or both, in either order.
+func (p *parser) moment() (time.Time, bool) {
+	if match := isoRegex.FindStringSubmatch(p.peek()); match != nil {
+		// 2026-11-01T14:00 is a date and a time in one word
+		t, err := time.ParseInLocation("2006-01-02 15:04", match[1]+" "+match[2], p.now.Location())
+		if err != nil {
+			t, err = time.ParseInLocation("2006-01-02 3:04", match[1]+" "+match[2], p.now.Location())
+		}
+		if err == nil {
+			p.pos++
+			return t, true
+		}
+	}
+
+	day, weekday, hasDay := p.day()
+	hour, minute, hasClock := p.clock()
+	if !hasDay && hasClock {
+		day, weekday, hasDay = p.day()
+	}
+
+	if !hasDay && !hasClock {
+		return time.Time{}, false
+	}
+
+	if !hasClock {
+		hour, minute = defaultHour, 0
+	}
+
+	if !hasDay {
+		// The next time the clock shows this time
+		t := at(p.now, hour, minute)
+		if !t.After(p.now) {
+			t = at(p.now.AddDate(0, 0, 1), hour, minute)
+		}
+		return t, true
+	}
+
+	t := at(day, hour, minute)
+	if weekday && !t.After(p.now) {
+		t = at(day.AddDate(0, 0, 7), hour, minute)
+	}
+
+	return t, true
+}
+
+// day parses "today", "tomorrow", a weekday or a date. Weekdays report true so the
+// caller can move them to the next week when the time already passed today.
+func (p *parser) day() (day time.Time, weekday bool, ok bool) {
+	start := p.pos
+	p.accept("on")
+	next := p.accept("next")
+
+	word := p.peek()
+	switch {
+	case word == "today" && !next:
+		p.pos++
+		return p.now, false, true
+
+	case word == "tomorrow" && !next:
+		p.pos++
+		return p.now.AddDate(0, 0, 1), false, true
+
+	case isWeekday(word):
+		p.pos++
+		days := (int(weekdays[word]) - int(p.now.Weekday()) + 7) % 7
+		if next && days == 0 {
+			days = 7
+		}
+		return p.now.AddDate(0, 0, days), days == 0, true
+
+	case dateRegex.MatchString(word) && !next:
+		match := dateRegex.FindStringSubmatch(word)
+		year, _ := strconv.Atoi(match[1])
+		month, _ := strconv.Atoi(match[2])
+		date, _ := strconv.Atoi(match[3])
+		t := time.Date(year, time.Month(month), date, defaultHour, 0, 0, 0, p.now.Location())
+		if t.Month() != time.Month(month) || t.Day() != date {
+			break
+		}
+
+		p.pos++
+		return t, false, true
+	}
+
+	p.pos = start
+	return time.Time{}, false, false
+}
+
+// clock parses a time of day like "9am", "9:30 pm", "at 14:00", "noon" or "midnight".
+func (p *parser) clock() (hour int, minute int, ok bool) {
+	start := p.pos
+	p.accept("at")
+
+	switch word := p.peek(); word {
+	case "noon":
+		p.pos++
+		return 12, 0, true
+	case "midnight":
+		p.pos++
+		return 0, 0, true
+	}
+
+	match := clockRegex.FindStringSubmatch(p.peek())
+	if match == nil {
+		p.pos = start
+		return 0, 0, false
+	}
+
+	hour, _ = strconv.Atoi(match[1])
+	minute, _ = strconv.Atoi(match[2])
+	meridiem := match[3]
+	p.pos++
+
+	if meridiem == "" && (p.peek() == "am" || p.peek() == "pm") {
+		meridiem = p.peek()
+		p.pos++
+	}
+
+	// A bare number is not a time of day, "remind me 5 times" should stay text
+	if meridiem == "" && match[2] == "" {
+		p.pos = start
+		return 0, 0, false
+	}
+
+	if meridiem != "" {
+		if hour < 1 || hour > 12 {
+			p.pos = start
+			return 0, 0, false
+		}
+		hour %= 12
+		if meridiem == "pm" {
+			hour += 12
+		}
+	}
+
+	if hour > 23 || minute > 59 {
+		p.pos = start
+		return 0, 0, false
+	}
+
+	return hour, minute, true
+}
+
+// recurring parses what follows "every": an interval, days of the week or both with a time of day.
+func (p *parser) recurring() (*Result, error) {
+	rule := &Rule{Interval: 1}
+
+	word := p.peek()
+	switch {
+	case word == "weekday" || word == "weekdays":
+		p.pos++
+		rule.Freq = Weekly
+		rule.ByDay = []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+
+	case word == "weekend" || word == "weekends":
+		p.pos++
+		rule.Freq = Weekly
+		rule.ByDay = []time.Weekday{time.Saturday, time.Sunday}
+
+	case isWeekday(strings.Split(word, ",")[0]):
+		rule.Freq = Weekly
+		rule.ByDay = p.weekdays()
+
+	case units[word] != "":
+		// "every day", "every week"
+		p.pos++
+		freq, ok := frequencies[units[word]]
+		if !ok {
+			return nil, fmt.Errorf("reminders can't repeat every %s", word)
+		}
+		rule.Freq = freq
+
+	default:
+		o, ok, err := p.offset()
+		if err != nil {
+			return nil, err
+		} else if !ok {
+			return nil, fmt.Errorf("I don't understand how often \"every %s\" is", word)
+		}
+
+		if err := rule.fromOffset(o); err != nil {
+			return nil, err
+		}
+	}
+
+	return p.recurringAt(rule)
+}
+
+// recurringAt finds the first occurrence of a rule on days, at an optional time of day.
+func (p *parser) recurringAt(rule *Rule) (*Result, error) {
+	if rule.Freq == Minutely || rule.Freq == Hourly {
+		return &Result{Time: rule.Next(p.now), Rule: rule}, nil
+	}
+
+	// "every monday and thursday" may also be written with "on"
+	if rule.Freq == Weekly && len(rule.ByDay) == 0 && p.accept("on") {
+		rule.ByDay = p.weekdays()
+	}
+
+	hour, minute, ok := p.clock()
+	if !ok {
+		hour, minute = defaultHour, 0
+	}
+
+	t := at(p.now, hour, minute)
+	if len(rule.ByDay) > 0 {
+		for !t.After(p.now) || !slices.Contains(rule.ByDay, t.Weekday()) {
+			t = at(t.AddDate(0, 0, 1), hour, minute)
+		}
+	} else if !t.After(p.now) {
+		t = at(p.now.AddDate(0, 0, 1), hour, minute)
+	}
+
+	if rule.Freq == Monthly {
+		rule.ByMonthDay = t.Day()
+	}
+
+	return &Result{Time: t, Rule: rule}, nil
+}
+
+// weekdays parses a list like "monday", "mon,thu" or "monday and friday".
+func (p *parser) weekdays() []time.Weekday {
+	days := []time.Weekday{}
+	for {
+		names := strings.Split(p.peek(), ",")
+		parsed := []time.Weekday{}
+		for _, name := range names {
+			day, ok := weekdays[name]
+			if !ok {
+				parsed = nil
+				break
+			}
+			parsed = append(parsed, day)
+		}
+
+		if len(parsed) == 0 {
+			break
+		}
+
+		days = append(days, parsed...)
+		p.pos++
+
+		if p.peek() == "and" && isWeekday(strings.Split(p.lookahead(1), ",")[0]) {
+			p.pos++
+		}
+	}
+
+	rule := &Rule{ByDay: days}
+	rule.sortDays()
+	return rule.ByDay
+}
+
+func isWeekday(word string) bool {
+	_, ok := weekdays[word]
+	return ok
+}
+
+// fromOffset turns an interval like "2h" or "3 days" into the rule's frequency.
+func (r *Rule) fromOffset(o offset) error {
+	switch {
+	case o.months > 0 && o.days == 0 && o.duration == 0:
+		r.Freq, r.Interval = Monthly, o.months
+	case o.months == 0 && o.days > 0 && o.days%7 == 0 && o.duration == 0:
+		r.Freq, r.Interval = Weekly, o.days/7
+	case o.months == 0 && o.days > 0 && o.duration == 0:
+		r.Freq, r.Interval = Daily, o.days
+	case o.months == 0 && o.days == 0 && o.duration%time.Hour == 0:
+		r.Freq, r.Interval = Hourly, int(o.duration/time.Hour)
+	case o.months == 0 && o.days == 0 && o.duration%time.Minute == 0:
+		r.Freq, r.Interval = Minutely, int(o.duration/time.Minute)
+	default:
+		return fmt.Errorf("reminders can only repeat in whole minutes, hours, days, weeks or months")
+	}
+
+	return nil
+}
+
+func at(day time.Time, hour int, minute int) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, day.Location())
+}
@@ -0,0 +1,329 @@
+package when
+
+import (
+	"strings"
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func berlin(t *testing.T) *time.Location {
+	t.Helper()
+
+	loc, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loc
+}
+
+func TestParse(t *testing.T) {
+	loc := berlin(t)
+	date := func(month time.Month, day int, hour int, minute int) time.Time {
+		return time.Date(2026, month, day, hour, minute, 0, 0, loc)
+	}
+
+	// A Wednesday, four days before the clocks go forward
+	now := date(time.March, 25, 10, 0)
+
+	tests := []struct {
+		input string
+		want  time.Time
+		rule  string // Empty for one-off reminders
+		used  int
+	}{
+		// Relative
+		{"5m", date(time.March, 25, 10, 5), "", 1},
+		{"1d12h", date(time.March, 26, 22, 0), "", 1},
+		{"in 2 weeks", date(time.April, 8, 10, 0), "", 3},
+		{"1 hour and 30 minutes", date(time.March, 25, 11, 30), "", 5},
+		{"2 days and then some", date(time.March, 27, 10, 0), "", 2},
+		{"3mo", date(time.June, 25, 10, 0), "", 1},
+		{"36500 days", date(time.March, 25, 10, 0).AddDate(0, 0, 36500), "", 2},
+
+		// Days keep the time of day across DST, hours don't
+		{"in 4 days", date(time.March, 29, 10, 0), "", 3},
+		{"in 96h", date(time.March, 29, 11, 0), "", 2},
+
+		// Absolute
+		{"tomorrow at 9am", date(time.March, 26, 9, 0), "", 3},
+		{"today 17:30", date(time.March, 25, 17, 30), "", 2},
+		{"17:30 tomorrow", date(time.March, 26, 17, 30), "", 2},
+		{"8am", date(time.March, 26, 8, 0), "", 1},
+		{"9:30 pm", date(time.March, 25, 21, 30), "", 2},
+		{"noon", date(time.March, 25, 12, 0), "", 1},
+		{"midnight", date(time.March, 26, 0, 0), "", 1},
+		{"2026-11-01 14:00", date(time.November, 1, 14, 0), "", 2},
+		{"2026-11-01T14:00", date(time.November, 1, 14, 0), "", 1},
+		{"2026-11-01", date(time.November, 1, 9, 0), "", 1},
+		{"tomorrow, take a break", date(time.March, 26, 9, 0), "", 1},
+
+		// Weekdays
+		{"friday 17:30", date(time.March, 27, 17, 30), "", 2},
+		{"on Monday", date(time.March, 30, 9, 0), "", 2},
+		{"wednesday 11am", date(time.March, 25, 11, 0), "", 2},
+		{"wednesday 9am", date(time.April, 1, 9, 0), "", 2},
+		{"next wednesday", date(time.April, 1, 9, 0), "", 2},
+		{"at 8pm on sat", date(time.March, 28, 20, 0), "", 4},
+
+		// Recurring
+		{"every monday 10:00", date(time.March, 30, 10, 0), "FREQ=WEEKLY;INTERVAL=1;BYDAY=MO", 3},
+		{"every weekday at 8am", date(time.March, 26, 8, 0), "FREQ=WEEKLY;INTERVAL=1;BYDAY=MO,TU,WE,TH,FR", 4},
+		{"every weekend", date(time.March, 28, 9, 0), "FREQ=WEEKLY;INTERVAL=1;BYDAY=SA,SU", 2},
+		{"every mon,thu", date(time.March, 26, 9, 0), "FREQ=WEEKLY;INTERVAL=1;BYDAY=MO,TH", 2},
+		{"every 2 weeks on tuesday and thursday", date(time.March, 26, 9, 0), "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU,TH", 7},
+		{"every 2 hours", date(time.March, 25, 12, 0), "FREQ=HOURLY;INTERVAL=2", 3},
+		{"every 1h30m", date(time.March, 25, 11, 30), "FREQ=MINUTELY;INTERVAL=90", 2},
+		{"every 2 days at 7pm", date(time.March, 25, 19, 0), "FREQ=DAILY;INTERVAL=2", 5},
+		{"daily", date(time.March, 26, 9, 0), "FREQ=DAILY;INTERVAL=1", 1},
+		{"every day at noon stand up", date(time.March, 25, 12, 0), "FREQ=DAILY;INTERVAL=1", 4},
+		{"every month", date(time.March, 26, 9, 0), "FREQ=MONTHLY;INTERVAL=1;BYMONTHDAY=26", 2},
+		{"monthly at 11:00", date(time.March, 25, 11, 0), "FREQ=MONTHLY;INTERVAL=1;BYMONTHDAY=25", 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, used, err := Parse(strings.Fields(tt.input), now)
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+
+			if !result.Time.Equal(tt.want) {
+				t.Errorf("time = %s, want %s", result.Time, tt.want)
+			}
+			if used != tt.used {
+				t.Errorf("used %d words, want %d", used, tt.used)
+			}
+
+			rule := ""
+			if result.Rule != nil {
+				rule = result.Rule.String()
+			}
+			if rule != tt.rule {
+				t.Errorf("rule = %q, want %q", rule, tt.rule)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	now := time.Date(2026, time.March, 25, 10, 0, 0, 0, berlin(t))
+
+	tests := []struct {
+		input string
+		err   string // Part of the error
+	}{
+		{"soon", "don't understand"},
+		{"at 5 call mom", "don't understand"},
+		{"25:00", "don't understand"},
+		{"13pm", "don't understand"},
+		{"2026-02-30", "don't understand"},
+		{"2026-01-01", "in the past"},
+		{"every year", "how often"},
+		{"every second", "can't repeat"},
+		{"every 90 seconds", "whole minutes"},
+		{"99999999999999999999d", "years ahead"},
+		{"in 9223372036854775807 hours", "years ahead"},
+		{"36501 days", "years ahead"},
+		{"1300 months", "years ahead"},
+		{"1200mo 1d", "years ahead"},
+		{"every 99999999999999 days", "years ahead"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, _, err := Parse(strings.Fields(tt.input), now)
+			if err == nil {
+				t.Fatalf("Parse = %s, want an error", result.Time)
+			}
+			if !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("err = %v, want it to mention %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestRuleNext(t *testing.T) {
+	loc := berlin(t)
+	date := func(year int, month time.Month, day int, hour int, minute int) time.Time {
+		return time.Date(year, month, day, hour, minute, 0, 0, loc)
+	}
+	weekly := func(interval int, days ...time.Weekday) *Rule {
+		return &Rule{Freq: Weekly, Interval: interval, ByDay: days}
+	}
+	monthly := func(interval int, day int) *Rule {
+		return &Rule{Freq: Monthly, Interval: interval, ByMonthDay: day}
+	}
+
+	tests := []struct {
+		name     string
+		rule     *Rule
+		previous time.Time
+		want     []time.Time // The next occurrences, in order
+	}{
+		{
+			name:     "minutes",
+			rule:     &Rule{Freq: Minutely, Interval: 90},
+			previous: date(2026, time.March, 25, 10, 0),
+			want:     []time.Time{date(2026, time.March, 25, 11, 30), date(2026, time.March, 25, 13, 0)},
+		},
+		{
+			name:     "hours across DST",
+			rule:     &Rule{Freq: Hourly, Interval: 1},
+			previous: date(2026, time.March, 29, 1, 0),
+			want:     []time.Time{date(2026, time.March, 29, 3, 0)},
+		},
+		{
+			name:     "days across DST",
+			rule:     &Rule{Freq: Daily, Interval: 1},
+			previous: date(2026, time.March, 28, 9, 0),
+			want:     []time.Time{date(2026, time.March, 29, 9, 0), date(2026, time.March, 30, 9, 0)},
+		},
+		{
+			name:     "every other week",
+			rule:     weekly(2),
+			previous: date(2026, time.March, 25, 9, 0),
+			want:     []time.Time{date(2026, time.April, 8, 9, 0), date(2026, time.April, 22, 9, 0)},
+		},
+		{
+			name:     "days of the week",
+			rule:     weekly(1, time.Monday, time.Thursday),
+			previous: date(2026, time.March, 23, 9, 0),
+			want:     []time.Time{date(2026, time.March, 26, 9, 0), date(2026, time.March, 30, 9, 0), date(2026, time.April, 2, 9, 0)},
+		},
+		{
+			name:     "days of every other week",
+			rule:     weekly(2, time.Monday, time.Thursday),
+			previous: date(2026, time.March, 23, 9, 0),
+			want:     []time.Time{date(2026, time.March, 26, 9, 0), date(2026, time.April, 6, 9, 0), date(2026, time.April, 9, 9, 0)},
+		},
+		{
+			name:     "sunday ends the week",
+			rule:     weekly(2, time.Saturday, time.Sunday),
+			previous: date(2026, time.March, 28, 9, 0),
+			want:     []time.Time{date(2026, time.March, 29, 9, 0), date(2026, time.April, 11, 9, 0)},
+		},
+		{
+			name:     "the 31st",
+			rule:     monthly(1, 31),
+			previous: date(2027, time.January, 31, 9, 0),
+			want: []time.Time{
+				date(2027, time.February, 28, 9, 0), date(2027, time.March, 31, 9, 0),
+				date(2027, time.April, 30, 9, 0), date(2027, time.May, 31, 9, 0),
+			},
+		},
+		{
+			name:     "leap years",
+			rule:     monthly(1, 30),
+			previous: date(2028, time.January, 30, 9, 0),
+			want:     []time.Time{date(2028, time.February, 29, 9, 0), date(2028, time.March, 30, 9, 0)},
+		},
+		{
+			name:     "every quarter",
+			rule:     monthly(3, 30),
+			previous: date(2026, time.November, 30, 9, 0),
+			want:     []time.Time{date(2027, time.February, 28, 9, 0), date(2027, time.May, 30, 9, 0)},
+		},
+		{
+			name:     "months of rules from before BYMONTHDAY",
+			rule:     &Rule{Freq: Monthly, Interval: 1},
+			previous: date(2026, time.January, 15, 9, 0),
+			want:     []time.Time{date(2026, time.February, 15, 9, 0), date(2026, time.March, 15, 9, 0)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := tt.previous
+			for i, want := range tt.want {
+				next = tt.rule.Next(next)
+				if !next.Equal(want) {
+					t.Fatalf("occurrence %d = %s, want %s", i+1, next, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRuleAfter(t *testing.T) {
+	rule := &Rule{Freq: Daily, Interval: 2}
+	previous := time.Date(2026, time.March, 1, 9, 0, 0, 0, time.UTC)
+
+	// Missed occurrences are skipped, keeping the rhythm of the first one
+	got := rule.After(previous, time.Date(2026, time.March, 10, 12, 0, 0, 0, time.UTC))
+	if want := time.Date(2026, time.March, 11, 9, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("After = %s, want %s", got, want)
+	}
+}
+
+func TestParseRule(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string // Empty when the rule is invalid
+	}{
+		{"FREQ=MINUTELY;INTERVAL=90", "FREQ=MINUTELY;INTERVAL=90"},
+		{"FREQ=HOURLY;INTERVAL=2", "FREQ=HOURLY;INTERVAL=2"},
+		{"FREQ=DAILY;INTERVAL=1", "FREQ=DAILY;INTERVAL=1"},
+		{"FREQ=DAILY", "FREQ=DAILY;INTERVAL=1"},
+		{"FREQ=WEEKLY;INTERVAL=1;BYDAY=MO,TH", "FREQ=WEEKLY;INTERVAL=1;BYDAY=MO,TH"},
+		{"FREQ=WEEKLY;BYDAY=SU,TH,MO,TH", "FREQ=WEEKLY;INTERVAL=1;BYDAY=MO,TH,SU"},
+		{"FREQ=MONTHLY;INTERVAL=1;BYMONTHDAY=31", "FREQ=MONTHLY;INTERVAL=1;BYMONTHDAY=31"},
+		{"FREQ=MONTHLY;INTERVAL=3", "FREQ=MONTHLY;INTERVAL=3"},
+		{"FREQ=YEARLY", ""},
+		{"FREQ=DAILY;INTERVAL=0", ""},
+		{"FREQ=DAILY;INTERVAL=x", ""},
+		{"FREQ=WEEKLY;BYDAY=XX", ""},
+		{"FREQ=MONTHLY;BYMONTHDAY=32", ""},
+		{"FREQ=DAILY;COUNT=3", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		rule, err := ParseRule(tt.input)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("ParseRule(%q) = %s, want an error", tt.input, rule)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("ParseRule(%q) failed: %v", tt.input, err)
+			continue
+		}
+		if got := rule.String(); got != tt.want {
+			t.Errorf("ParseRule(%q) = %s, want %s", tt.input, got, tt.want)
+		}
+
+		// Written rules read back the same
+		again, err := ParseRule(rule.String())
+		if err != nil || again.String() != rule.String() {
+			t.Errorf("ParseRule(%q) = %v, %v, want %s back", rule.String(), again, err, rule)
+		}
+	}
+}
+
+func TestRuleDescribe(t *testing.T) {
+	tests := []struct {
+		rule string
+		want string
+	}{
+		{"FREQ=DAILY;INTERVAL=1", "every day"},
+		{"FREQ=HOURLY;INTERVAL=3", "every 3 hours"},
+		{"FREQ=WEEKLY;INTERVAL=1;BYDAY=MO", "every Monday"},
+		{"FREQ=WEEKLY;INTERVAL=1;BYDAY=MO,TH", "every Monday and Thursday"},
+		{"FREQ=WEEKLY;INTERVAL=1;BYDAY=MO,WE,FR", "every Monday, Wednesday and Friday"},
+		{"FREQ=WEEKLY;INTERVAL=2;BYDAY=TU", "every 2 weeks on Tuesday"},
+		{"FREQ=MONTHLY;INTERVAL=1;BYMONTHDAY=31", "every month"},
+	}
+
+	for _, tt := range tests {
+		rule, err := ParseRule(tt.rule)
+		if err != nil {
+			t.Fatalf("ParseRule(%q) failed: %v", tt.rule, err)
+		}
+		if got := rule.Describe(); got != tt.want {
+			t.Errorf("Describe(%s) = %q, want %q", tt.rule, got, tt.want)
+		}
+	}
+}
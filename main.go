@@ -9,6 +9,14 @@ import (
 )
 
 func main() {
+	// Inspect or migrate a data file without starting the bot
+	if len(os.Args) > 1 && os.Args[1] == "data" {
+		if err := runData(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	// Get config file path from args
 	configPath := ".chad"
 	if len(os.Args) > 1 {